@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2TokenMargin is a time before expiry the token is considered expired
+const oauth2TokenMargin = 30 * time.Second
+
+// Auth describes credentials added to the request. Only one of the schemes is expected.
+type Auth struct {
+	Basic  *BasicAuth  `json:"basic,omitempty"`
+	Bearer string      `json:"bearer,omitempty"`
+	OAuth2 *OAuth2Auth `json:"oauth2,omitempty"`
+}
+
+// BasicAuth is a username and password sent in Authorization header
+type BasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// OAuth2Auth describes how to obtain access token from authorization server
+type OAuth2Auth struct {
+	// absolute or relative to the host
+	TokenURL string `json:"tokenUrl"`
+	// client_credentials (default) or password
+	GrantType    string `json:"grantType,omitempty"`
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+	// resource owner credentials of password grant
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// client credentials are sent in the form instead of Authorization header
+	CredentialsInBody bool `json:"credentialsInBody,omitempty"`
+}
+
+// apply sets Authorization header of the request, placeholders are applied to credentials
+func (a *Auth) apply(req *http.Request, config *RequestConfig, tmplCtx *TemplateContext) error {
+	switch {
+	case a.Basic != nil:
+		req.SetBasicAuth(tmplCtx.ApplyTo(a.Basic.Username), tmplCtx.ApplyTo(a.Basic.Password))
+
+	case a.Bearer != "":
+		req.Header.Set("Authorization", "Bearer "+tmplCtx.ApplyTo(a.Bearer))
+
+	case a.OAuth2 != nil:
+		o := *a.OAuth2
+		for _, field := range []*string{&o.TokenURL, &o.ClientID, &o.ClientSecret, &o.Username, &o.Password, &o.Scope} {
+			*field = tmplCtx.ApplyTo(*field)
+		}
+
+		if tmplCtx.HasErrors() {
+			return tmplCtx.Error()
+		}
+
+		token, err := oauth2Tokens.get(o, config)
+		if err != nil {
+			return fmt.Errorf("cannot obtain OAuth2 token: %s", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if tmplCtx.HasErrors() {
+		return tmplCtx.Error()
+	}
+
+	return nil
+}
+
+// oauth2Tokens are shared by all suites, so the token is requested once per credentials until it expires
+var oauth2Tokens = &oauth2TokenCache{tokens: make(map[OAuth2Auth]*oauth2Token)}
+
+type oauth2TokenCache struct {
+	tokens map[OAuth2Auth]*oauth2Token
+	mutex  sync.Mutex
+}
+
+type oauth2Token struct {
+	value string
+	// zero if token doesn't expire
+	expires time.Time
+	// held while token is requested, concurrent calls wait for the same token
+	mutex sync.Mutex
+}
+
+func (c *oauth2TokenCache) get(o OAuth2Auth, config *RequestConfig) (string, error) {
+	c.mutex.Lock()
+	token, ok := c.tokens[o]
+	if !ok {
+		token = &oauth2Token{}
+		c.tokens[o] = token
+	}
+	c.mutex.Unlock()
+
+	token.mutex.Lock()
+	defer token.mutex.Unlock()
+
+	if token.value != "" && (token.expires.IsZero() || time.Now().Add(oauth2TokenMargin).Before(token.expires)) {
+		return token.value, nil
+	}
+
+	debugf("requesting OAuth2 token: %s", o.TokenURL)
+
+	value, expiresIn, err := requestOAuth2Token(o, config)
+	if err != nil {
+		return "", err
+	}
+
+	token.value = value
+	token.expires = time.Time{}
+	if expiresIn > 0 {
+		token.expires = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+
+	return token.value, nil
+}
+
+// requestOAuth2Token returns access token and its lifetime in seconds (zero if not specified)
+func requestOAuth2Token(o OAuth2Auth, config *RequestConfig) (string, int64, error) {
+	tokenURL, err := urlPrefix(o.TokenURL)
+	if err != nil {
+		return "", 0, errors.New("invalid token url: " + o.TokenURL)
+	}
+
+	grantType := o.GrantType
+	if grantType == "" {
+		grantType = "client_credentials"
+	}
+
+	form := url.Values{"grant_type": {grantType}}
+	if o.Scope != "" {
+		form.Set("scope", o.Scope)
+	}
+	if grantType == "password" {
+		form.Set("username", o.Username)
+		form.Set("password", o.Password)
+	}
+	if o.CredentialsInBody {
+		form.Set("client_id", o.ClientID)
+		form.Set("client_secret", o.ClientSecret)
+	}
+
+	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !o.CredentialsInBody {
+		req.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+	}
+
+	ctx, cancel := config.requestContext()
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	// cookies of the test case are not shared with authorization server
+	client := (&RequestConfig{transport: config.transport}).client()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, config.timeoutError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, config.timeoutError(ctx, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, body)
+	}
+
+	var token struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+
+	err = json.Unmarshal(body, &token)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid token response: %s", err)
+	}
+
+	if token.AccessToken == "" {
+		return "", 0, errors.New("access_token is missing in the token response")
+	}
+
+	expiresIn, _ := token.ExpiresIn.Int64()
+
+	return token.AccessToken, expiresIn, nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuthApply(t *testing.T) {
+	vars := NewVars("")
+	vars.Add("token", "abc")
+	vars.Add("password", "secret")
+
+	tests := []struct {
+		name string
+		auth Auth
+		want string
+	}{
+		{name: "basic", auth: Auth{Basic: &BasicAuth{Username: "john", Password: "{password}"}}, want: "Basic am9objpzZWNyZXQ="},
+		{name: "bearer", auth: Auth{Bearer: "{token}"}, want: "Bearer abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "http://example.com", nil)
+
+			err := tt.auth.apply(req, &RequestConfig{}, NewTemplateContext(vars))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := req.Header.Get("Authorization"); got != tt.want {
+				t.Errorf("unexpected Authorization header. Expected: %s, Actual: %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPopulateRequest_HeadersPrecedence(t *testing.T) {
+	config := &RequestConfig{
+		Headers: map[string]string{"Authorization": "Bearer global", "X-Launch": "l-1"},
+		Auth:    &Auth{Bearer: "auth"},
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{name: "auth over global header", want: "Bearer auth"},
+		{name: "call header over auth", headers: map[string]string{"authorization": "Bearer call"}, want: "Bearer call"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := populateRequest(config, On{Method: "GET", URL: "http://example.com", Headers: tt.headers}, "", NewTemplateContext(NewVars("")))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := req.Header.Values("Authorization"); len(got) != 1 || got[0] != tt.want {
+				t.Errorf("unexpected Authorization header. Expected: %s, Actual: %v", tt.want, got)
+			}
+
+			if req.Header.Get("X-Launch") != "l-1" {
+				t.Errorf("global header is not sent %v", req.Header)
+			}
+		})
+	}
+}
+
+func newTokenServer(expiresIn int, requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID == "" {
+			clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+
+		if clientSecret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "invalid_client"}`)
+			return
+		}
+
+		*requests = append(*requests, r.PostForm.Encode())
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": %d}`, len(*requests), expiresIn)
+	}))
+}
+
+func TestOAuth2TokenIsCached(t *testing.T) {
+	initLogger()
+
+	var requests []string
+	server := newTokenServer(3600, &requests)
+	defer server.Close()
+
+	auth := Auth{OAuth2: &OAuth2Auth{TokenURL: server.URL + "/token", ClientID: "cached-client", ClientSecret: "secret", Scope: "read"}}
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+
+		err := auth.apply(req, &RequestConfig{}, NewTemplateContext(NewVars("")))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := req.Header.Get("Authorization"); got != "Bearer token-1" {
+			t.Errorf("unexpected Authorization header %s", got)
+		}
+	}
+
+	if len(requests) != 1 || requests[0] != "grant_type=client_credentials&scope=read" {
+		t.Errorf("expected single token request, got %v", requests)
+	}
+}
+
+func TestOAuth2TokenIsRefreshedBeforeExpiry(t *testing.T) {
+	initLogger()
+
+	var requests []string
+	server := newTokenServer(10, &requests) // expires within the margin
+	defer server.Close()
+
+	auth := Auth{OAuth2: &OAuth2Auth{TokenURL: server.URL, GrantType: "password", ClientID: "expiring-client", ClientSecret: "secret", Username: "john", Password: "pwd", CredentialsInBody: true}}
+
+	for i := 1; i <= 2; i++ {
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+
+		err := auth.apply(req, &RequestConfig{}, NewTemplateContext(NewVars("")))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := req.Header.Get("Authorization"); got != fmt.Sprintf("Bearer token-%d", i) {
+			t.Errorf("unexpected Authorization header %s", got)
+		}
+	}
+
+	if len(requests) != 2 || !strings.Contains(requests[0], "grant_type=password&password=pwd&username=john") {
+		t.Errorf("expected token to be requested twice with password grant, got %v", requests)
+	}
+}
+
+func TestOAuth2TokenError(t *testing.T) {
+	initLogger()
+
+	var requests []string
+	server := newTokenServer(3600, &requests)
+	defer server.Close()
+
+	auth := Auth{OAuth2: &OAuth2Auth{TokenURL: server.URL, ClientID: "invalid-client", ClientSecret: "wrong"}}
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+
+	err := auth.apply(req, &RequestConfig{}, NewTemplateContext(NewVars("")))
+	if err == nil || !strings.Contains(err.Error(), "unexpected status code 401") {
+		t.Errorf("expected token error, got %v", err)
+	}
+}
+
+func TestRunSuite_AuthPrecedence(t *testing.T) {
+	initLogger()
+
+	var headers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	statusCode := 200
+	get := func(auth *Auth, header string) Call {
+		c := Call{On: On{Method: "GET", URL: server.URL}, Expect: Expect{StatusCode: &statusCode}, Auth: auth}
+		if header != "" {
+			c.On.Headers = map[string]string{"Authorization": header}
+		}
+		return c
+	}
+
+	suite := TestSuite{
+		Cases: []TestCase{
+			{Name: "environment", Calls: []Call{get(nil, "")}},
+			{Name: "test case", Auth: &Auth{Bearer: "case"}, Calls: []Call{get(nil, ""), get(&Auth{Bearer: "call"}, ""), get(nil, "Custom explicit")}},
+		},
+	}
+
+	results := runSuite(&RunConfig{requestConfig: &RequestConfig{Auth: &Auth{Bearer: "env"}}, rewriteConfig: &RewriteConfig{}}, suite)
+
+	for _, result := range results {
+		if result.hasError() {
+			t.Errorf("%s: unexpected error: %s", result.Case.Name, result.Error())
+		}
+	}
+
+	expected := "Bearer env, Bearer case, Bearer call, Custom explicit"
+	if actual := strings.Join(headers, ", "); actual != expected {
+		t.Errorf("unexpected Authorization headers. Expected: %s, Actual: %s", expected, actual)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// CoverageReporter reports documented operations and response codes exercised by executed calls.
+type CoverageReporter struct {
+	// output file, html or json by extension
+	OutPath string
+
+	spec *OpenAPISpec
+	// documented response codes (e.g. 200, 4XX, default) hit by operation
+	hits map[*OpenAPIOperation]map[string]bool
+	// calls of unknown operations and undocumented response codes
+	undocumented map[string]bool
+	mutex        sync.Mutex
+}
+
+type coverageReport struct {
+	Summary      coverageSummary     `json:"summary"`
+	Operations   []coverageOperation `json:"operations"`
+	Undocumented []string            `json:"undocumented"`
+}
+
+type coverageSummary struct {
+	Operations         int     `json:"operations"`
+	CoveredOperations  int     `json:"coveredOperations"`
+	Responses          int     `json:"responses"`
+	CoveredResponses   int     `json:"coveredResponses"`
+	OperationsCoverage float64 `json:"operationsCoverage"`
+	ResponsesCoverage  float64 `json:"responsesCoverage"`
+}
+
+type coverageOperation struct {
+	Method      string             `json:"method"`
+	Path        string             `json:"path"`
+	OperationID string             `json:"operationId,omitempty"`
+	Covered     bool               `json:"covered"`
+	Responses   []coverageResponse `json:"responses"`
+}
+
+type coverageResponse struct {
+	Code    string `json:"code"`
+	Covered bool   `json:"covered"`
+}
+
+func (r *CoverageReporter) Init() {
+	r.hits = make(map[*OpenAPIOperation]map[string]bool)
+	r.undocumented = make(map[string]bool)
+}
+
+func (r *CoverageReporter) Report(results []TestResult) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, result := range results {
+		for _, trace := range result.Traces {
+			if trace.RequestURL == "" || trace.StatusCode == 0 {
+				continue
+			} // request is not sent
+
+			u, err := url.Parse(trace.RequestURL)
+			if err != nil {
+				continue
+			}
+
+			op := r.spec.FindOperation(trace.RequestMethod, u.Path)
+			if op == nil {
+				r.undocumented[trace.RequestMethod+" "+u.Path] = true
+				continue
+			}
+
+			if r.hits[op] == nil {
+				r.hits[op] = make(map[string]bool)
+			}
+
+			if _, code := op.Response(trace.StatusCode); code != "" {
+				r.hits[op][code] = true
+			} else {
+				r.undocumented[fmt.Sprintf("%s %s %d", op.Method, op.Path, trace.StatusCode)] = true
+			} // operation is exercised, but the response is not documented
+		}
+	}
+}
+
+// collect builds report of all documented operations
+func (r *CoverageReporter) collect() coverageReport {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	report := coverageReport{
+		Operations:   make([]coverageOperation, 0, len(r.spec.Operations)),
+		Undocumented: make([]string, 0, len(r.undocumented)),
+	}
+
+	for _, op := range r.spec.Operations {
+		_, covered := r.hits[op]
+
+		item := coverageOperation{
+			Method:      op.Method,
+			Path:        op.Path,
+			OperationID: op.OperationID,
+			Covered:     covered,
+			Responses:   make([]coverageResponse, 0, len(op.Responses)),
+		}
+
+		for code := range op.Responses {
+			item.Responses = append(item.Responses, coverageResponse{Code: code, Covered: r.hits[op][code]})
+		}
+		sort.Slice(item.Responses, func(i, j int) bool {
+			return item.Responses[i].Code < item.Responses[j].Code
+		})
+
+		report.Summary.Operations++
+		report.Summary.Responses += len(item.Responses)
+		if covered {
+			report.Summary.CoveredOperations++
+		}
+		for _, resp := range item.Responses {
+			if resp.Covered {
+				report.Summary.CoveredResponses++
+			}
+		}
+
+		report.Operations = append(report.Operations, item)
+	}
+
+	for call := range r.undocumented {
+		report.Undocumented = append(report.Undocumented, call)
+	}
+	sort.Strings(report.Undocumented)
+
+	report.Summary.OperationsCoverage = percent(report.Summary.CoveredOperations, report.Summary.Operations)
+	report.Summary.ResponsesCoverage = percent(report.Summary.CoveredResponses, report.Summary.Responses)
+
+	return report
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(int(float64(part)*1000/float64(total))) / 10
+}
+
+func (r *CoverageReporter) Flush() {
+	report := r.collect()
+
+	fmt.Println("API Coverage Summary")
+	fmt.Println("-------------------------------")
+
+	w := tabwriter.NewWriter(os.Stdout, 4, 2, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "Operations:\t %d/%d (%.1f%%)\n", report.Summary.CoveredOperations, report.Summary.Operations, report.Summary.OperationsCoverage)
+	fmt.Fprintf(w, "Responses:\t %d/%d (%.1f%%)\n", report.Summary.CoveredResponses, report.Summary.Responses, report.Summary.ResponsesCoverage)
+	w.Flush()
+
+	for _, op := range report.Operations {
+		if !op.Covered {
+			fmt.Printf("  not covered: %s %s\n", op.Method, op.Path)
+		}
+	}
+
+	for _, call := range report.Undocumented {
+		fmt.Printf("  undocumented: %s\n", call)
+	}
+
+	fmt.Println()
+
+	if r.OutPath == "" {
+		return
+	}
+
+	var data []byte
+	if strings.HasSuffix(strings.ToLower(r.OutPath), ".html") {
+		var buf bytes.Buffer
+		err := coverageReportTemplate.Execute(&buf, report)
+		if err != nil {
+			panic(err)
+		}
+		data = buf.Bytes()
+	} else {
+		var err error
+		data, err = json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	writeReportFile(r.OutPath, data)
+}
+
+// NewCoverageReporter creates reporter of API coverage against OpenAPI spec
+func NewCoverageReporter(spec *OpenAPISpec, outPath string) Reporter {
+	return &CoverageReporter{spec: spec, OutPath: outPath}
+}
+
+var coverageReportTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>API coverage</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+td, th { border-bottom: 1px solid #ddd; padding: 0.3em 0.8em; text-align: left; }
+.covered { color: #2e7d32; }
+.missed { color: #c62828; }
+</style>
+</head>
+<body>
+<h1>API coverage</h1>
+<p>Operations: {{.Summary.CoveredOperations}}/{{.Summary.Operations}} ({{.Summary.OperationsCoverage}}%),
+responses: {{.Summary.CoveredResponses}}/{{.Summary.Responses}} ({{.Summary.ResponsesCoverage}}%)</p>
+<table>
+<tr><th>Method</th><th>Path</th><th>Operation</th><th>Responses</th></tr>
+{{range .Operations}}
+<tr class="{{if .Covered}}covered{{else}}missed{{end}}">
+<td>{{.Method}}</td><td>{{.Path}}</td><td>{{.OperationID}}</td>
+<td>{{range .Responses}}<span class="{{if .Covered}}covered{{else}}missed{{end}}">{{.Code}}</span> {{end}}</td>
+</tr>
+{{end}}
+</table>
+{{if .Undocumented}}
+<h2>Undocumented calls</h2>
+<ul>{{range .Undocumented}}<li>{{.}}</li>{{end}}</ul>
+{{end}}
+</body>
+</html>
+`))
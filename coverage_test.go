@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCoverageReporter(t *testing.T) {
+	// given
+	spec, err := loadOpenAPISpec(writeTestOpenAPISpec(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outPath := filepath.Join(t.TempDir(), "coverage.json")
+	reporter := NewCoverageReporter(spec, outPath)
+	reporter.Init()
+
+	// when
+	reporter.Report([]TestResult{{Traces: []*CallTrace{
+		{RequestMethod: "GET", RequestURL: "http://example.com/api/v1/users/42?fields=name", StatusCode: 200},
+		{RequestMethod: "GET", RequestURL: "http://example.com/api/v1/users/7", StatusCode: 200},
+		{RequestMethod: "GET", RequestURL: "http://example.com/api/v1/users/7", StatusCode: 500},
+		{RequestMethod: "DELETE", RequestURL: "http://example.com/api/v1/users/7", StatusCode: 204},
+		{RequestMethod: "POST", RequestURL: "http://example.com/api/v1/users"}, // not sent
+	}}})
+	reporter.Flush()
+
+	// then
+	content, err := ioutil.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := coverageReport{}
+	err = json.Unmarshal(content, &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Summary.Operations != 3 || report.Summary.CoveredOperations != 1 {
+		t.Errorf("unexpected operations coverage %+v", report.Summary)
+	}
+
+	if report.Summary.Responses != 4 || report.Summary.CoveredResponses != 1 {
+		t.Errorf("unexpected responses coverage %+v", report.Summary)
+	}
+
+	expected := "DELETE /api/v1/users/7,GET /users/{id} 500"
+	if strings.Join(report.Undocumented, ",") != expected {
+		t.Errorf("unexpected undocumented calls %v", report.Undocumented)
+	}
+}
+
+func TestCoverageReporter_SetupCalls(t *testing.T) {
+	initLogger()
+
+	// given
+	spec, err := loadOpenAPISpec(writeTestOpenAPISpec(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	created, ok := 201, 200
+	suite := TestSuite{
+		BeforeAll: []Call{{On: On{Method: "POST", URL: server.URL + "/users"}, Expect: Expect{StatusCode: &created}}},
+		Cases:     []TestCase{{Name: "get user", Calls: []Call{{On: On{Method: "GET", URL: server.URL + "/users/42"}, Expect: Expect{StatusCode: &ok}}}}},
+	}
+
+	reporter := NewCoverageReporter(spec, "").(*CoverageReporter)
+	reporter.Init()
+
+	// when
+	reporter.Report(runSuite(&RunConfig{requestConfig: &RequestConfig{}, rewriteConfig: &RewriteConfig{}}, suite))
+
+	// then
+	report := reporter.collect()
+	for _, op := range report.Operations {
+		if covered := op.Path != "/users/me"; op.Covered != covered {
+			t.Errorf("unexpected coverage of %s %s: %v", op.Method, op.Path, op.Covered)
+		}
+	}
+}
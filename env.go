@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// envFileName is a default name of environment profiles file located in the suites root.
+const envFileName = "bozr.env.json"
+
+// EnvProfile describes settings of a particular environment tests are run against (e.g. local, staging).
+type EnvProfile struct {
+	BaseURL string            `json:"base_url"`
+	Headers map[string]string `json:"headers"`
+	TLS     *TLSOptions       `json:"tls"`
+	Vars    map[string]any    `json:"vars"`
+	// default credentials of all calls
+	Auth *Auth `json:"auth"`
+	// default signing of all calls
+	Sign *Signing `json:"sign"`
+}
+
+// TLSOptions describes TLS settings of the HTTP client.
+// Paths are relative to the file options are loaded from.
+type TLSOptions struct {
+	Insecure bool   `json:"insecure"`
+	CAFile   string `json:"caFile"`
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+	// server name to verify certificate against and to send in SNI
+	ServerName string `json:"serverName"`
+	// lowest accepted TLS version, e.g. 1.2
+	MinVersion string `json:"minVersion"`
+
+	baseDir string
+}
+
+// tlsVersions are TLS protocol versions by short name
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsVersionName returns short name of TLS protocol version, e.g. 1.2
+func tlsVersionName(version uint16) string {
+	for name, v := range tlsVersions {
+		if v == version {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("0x%04x", version)
+}
+
+// defaultEnvFile returns path to environment profiles file in the suites root.
+// Root is a directory itself or a directory of a suite file.
+func defaultEnvFile(suitesPath string) string {
+	root := suitesPath
+
+	info, err := os.Stat(suitesPath)
+	if err == nil && !info.IsDir() {
+		root = filepath.Dir(suitesPath)
+	}
+
+	return filepath.Join(root, envFileName)
+}
+
+// loadEnvProfile reads profile with specified name from environment profiles file.
+func loadEnvProfile(path, name string) (*EnvProfile, error) {
+	content, err := readSuiteFile(path) // yaml is supported as well
+	if err != nil {
+		return nil, fmt.Errorf("cannot read environment profiles: %s", err)
+	}
+
+	profiles := make(map[string]*EnvProfile)
+	err = json.Unmarshal(content, &profiles)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse environment profiles %s: %s", path, err)
+	}
+
+	profile, ok := profiles[name]
+	if !ok || profile == nil {
+		return nil, fmt.Errorf("environment profile %#v is not defined in %s", name, path)
+	}
+
+	if profile.TLS != nil {
+		profile.TLS.baseDir = filepath.Dir(path)
+	}
+
+	return profile, nil
+}
+
+// withOverrides returns options where specified fields of overrides (e.g. command line flags) take precedence.
+// Paths of merged options are resolved, as they could be relative to different directories.
+func (o *TLSOptions) withOverrides(overrides *TLSOptions) *TLSOptions {
+	if overrides == nil || *overrides == (TLSOptions{}) {
+		return o
+	}
+
+	merged := TLSOptions{}
+	if o != nil {
+		merged = *o
+		merged.CAFile, merged.CertFile, merged.KeyFile = o.path(o.CAFile), o.path(o.CertFile), o.path(o.KeyFile)
+		merged.baseDir = ""
+	}
+
+	merged.Insecure = merged.Insecure || overrides.Insecure
+
+	if overrides.ServerName != "" {
+		merged.ServerName = overrides.ServerName
+	}
+	if overrides.MinVersion != "" {
+		merged.MinVersion = overrides.MinVersion
+	}
+	if overrides.CAFile != "" {
+		merged.CAFile = overrides.path(overrides.CAFile)
+	}
+	if overrides.CertFile != "" {
+		merged.CertFile = overrides.path(overrides.CertFile)
+	}
+	if overrides.KeyFile != "" {
+		merged.KeyFile = overrides.path(overrides.KeyFile)
+	}
+
+	return &merged
+}
+
+// Config builds TLS configuration of the HTTP client.
+func (o *TLSOptions) Config() (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: o.Insecure, ServerName: o.ServerName}
+
+	if o.MinVersion != "" {
+		version, ok := tlsVersions[o.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS version %s, expected one of 1.0, 1.1, 1.2, 1.3", o.MinVersion)
+		}
+
+		config.MinVersion = version
+	}
+
+	if o.CAFile != "" {
+		pem, err := ioutil.ReadFile(o.path(o.CAFile))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA file: %s", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file: %s", o.CAFile)
+		}
+
+		config.RootCAs = pool
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.path(o.CertFile), o.path(o.KeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("cannot load client certificate: %s", err)
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+func (o *TLSOptions) path(p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+
+	return filepath.Join(o.baseDir, p)
+}
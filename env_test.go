@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadEnvProfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, envFileName)
+	os.WriteFile(path, []byte(`{
+		"local": {
+			"base_url": "http://localhost:8080",
+			"headers": {"X-Env": "local"},
+			"tls": {"insecure": true},
+			"vars": {"user": "admin"}
+		}
+	}`), 0644)
+
+	profile, err := loadEnvProfile(path, "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.BaseURL != "http://localhost:8080" || profile.Vars["user"] != "admin" || !profile.TLS.Insecure {
+		t.Errorf("unexpected profile: %+v", profile)
+	}
+
+	_, err = loadEnvProfile(path, "prod")
+	if err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+func TestNewRequestConfig_FlagsOverrideProfile(t *testing.T) {
+	profile := &EnvProfile{
+		Headers: map[string]string{"X-Env": "staging", "X-Team": "qa"},
+		TLS:     &TLSOptions{Insecure: true},
+	}
+
+	config, err := newRequestConfig([]string{"X-Env:local"}, nil, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Headers["X-Env"] != "local" || config.Headers["X-Team"] != "qa" {
+		t.Errorf("unexpected headers: %v", config.Headers)
+	}
+
+	if config.TLS == nil || !config.TLS.InsecureSkipVerify {
+		t.Errorf("unexpected tls config: %+v", config.TLS)
+	}
+
+	if config.client().Transport != config.withCookieJar().client().Transport {
+		t.Error("expected transport to be shared by clients")
+	}
+}
+
+func TestTLSOptions_WithOverrides(t *testing.T) {
+	profile := &TLSOptions{CAFile: "ca.pem", CertFile: "client.pem", KeyFile: "client.key", MinVersion: "1.2", baseDir: "/etc/bozr"}
+	flags := &TLSOptions{CertFile: "/tmp/other.pem", ServerName: "api.local", MinVersion: "1.3"}
+
+	merged := profile.withOverrides(flags)
+
+	expected := TLSOptions{
+		CAFile:     filepath.Join("/etc/bozr", "ca.pem"),
+		CertFile:   "/tmp/other.pem",
+		KeyFile:    filepath.Join("/etc/bozr", "client.key"),
+		ServerName: "api.local",
+		MinVersion: "1.3",
+	}
+	if *merged != expected {
+		t.Errorf("unexpected options. Expected: %+v, Actual: %+v", expected, *merged)
+	}
+
+	if profile.withOverrides(&TLSOptions{}) != profile {
+		t.Error("expected profile options without overrides")
+	}
+
+	if (*TLSOptions)(nil).withOverrides(nil) != nil {
+		t.Error("expected no options")
+	}
+}
+
+func TestNewRequestConfig_TLSFlags(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0) // rejected handshake is expected
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644)
+
+	// test server certificate is issued for example.com
+	config, err := newRequestConfig(nil, &TLSOptions{CAFile: caFile, ServerName: "example.com", MinVersion: "1.2"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := config.client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.TLS == nil || resp.TLS.Version < tls.VersionTLS12 {
+		t.Errorf("unexpected tls connection state: %+v", resp.TLS)
+	}
+
+	config, _ = newRequestConfig(nil, &TLSOptions{CAFile: caFile, ServerName: "other.com"}, nil)
+	_, err = config.client().Get(server.URL)
+	if err == nil || !strings.Contains(err.Error(), "other.com") {
+		t.Errorf("expected certificate error for other server name, got %v", err)
+	}
+
+	_, err = newRequestConfig(nil, &TLSOptions{MinVersion: "1.4"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "unsupported TLS version 1.4") {
+		t.Errorf("expected error of unsupported version, got %v", err)
+	}
+}
+
+func TestDefaultEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	suite := filepath.Join(dir, "a.suite.json")
+	os.WriteFile(suite, []byte("[]"), 0644)
+
+	if p := defaultEnvFile(suite); p != filepath.Join(dir, envFileName) {
+		t.Errorf("unexpected env file for suite file: %s", p)
+	}
+
+	if p := defaultEnvFile(dir); p != filepath.Join(dir, envFileName) {
+		t.Errorf("unexpected env file for suites dir: %s", p)
+	}
+}
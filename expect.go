@@ -2,10 +2,17 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"mime"
+	"net/http"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/xeipuuv/gojsonschema"
 )
@@ -41,6 +48,10 @@ func (e StatusCodeExpectation) desc() string {
 type BodySchemaExpectation struct {
 	schema      []byte
 	displayName string
+	// file path or url of the schema, used to resolve included schemas
+	location string
+	// downloads remote included schemas
+	fetch func(url string) ([]byte, error)
 }
 
 func (e BodySchemaExpectation) check(resp *Response) error {
@@ -50,6 +61,10 @@ func (e BodySchemaExpectation) check(resp *Response) error {
 		return e.checkJSON(resp)
 	}
 
+	if isXMLContentType(contentType) {
+		return e.checkXML(resp)
+	}
+
 	return fmt.Errorf("unsupported content type: %s", contentType)
 }
 
@@ -82,6 +97,120 @@ func (e BodySchemaExpectation) checkJSON(resp *Response) error {
 	return nil
 }
 
+// xsdSchemaCache keeps parsed XSD by location, so included schemas are not loaded on every check
+var xsdSchemaCache sync.Map
+
+func (e BodySchemaExpectation) loadXSD() (*XSDSchema, error) {
+	if e.location == "" {
+		return LoadXSD(e.schema, e.location, e.fetch)
+	}
+
+	if cached, ok := xsdSchemaCache.Load(e.location); ok {
+		debugf("loading XSD from the cache: %s", e.location)
+		return cached.(*XSDSchema), nil
+	}
+
+	schema, err := LoadXSD(e.schema, e.location, e.fetch)
+	if err != nil {
+		return nil, err
+	}
+
+	xsdSchemaCache.Store(e.location, schema)
+
+	return schema, nil
+}
+
+func (e BodySchemaExpectation) checkXML(resp *Response) error {
+	schema, err := e.loadXSD()
+	if err != nil {
+		return fmt.Errorf("failed to load schema file: %s", err)
+	}
+
+	errs := schema.Validate(resp.body)
+	if len(errs) > 0 {
+		msg := "Unexpected Body Schema:"
+		for _, desc := range errs {
+			msg = fmt.Sprintf(msg+"\n\t%s", desc)
+		}
+		return errors.New(msg)
+	}
+
+	return nil
+}
+
+// isXMLContentType returns true for xml media types, e.g. text/xml, application/soap+xml
+func isXMLContentType(contentType string) bool {
+	return contentType == "application/xml" || contentType == "text/xml" || strings.HasSuffix(contentType, "+xml")
+}
+
+// OpenAPIExpectation validates response against the operation of OpenAPI spec:
+// status code is documented, required headers are present and body matches the schema.
+type OpenAPIExpectation struct {
+	spec        *OpenAPISpec
+	operationID string
+	displayName string
+}
+
+func (e OpenAPIExpectation) check(resp *Response) error {
+	req := resp.http.Request
+
+	op := e.spec.FindOperation(req.Method, req.URL.Path)
+	if e.operationID != "" {
+		op = e.spec.OperationByID(e.operationID)
+	}
+
+	if op == nil {
+		return fmt.Errorf("no OpenAPI operation matches %s %s", req.Method, req.URL.Path)
+	}
+
+	documented, _ := op.Response(resp.http.StatusCode)
+	if documented == nil {
+		return fmt.Errorf("status code %d is not documented for operation %s", resp.http.StatusCode, op.Name())
+	}
+
+	msgs := []string{}
+
+	names := make([]string, 0, len(documented.Headers))
+	for name := range documented.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if documented.Headers[name].Required && resp.http.Header.Get(name) == "" {
+			msgs = append(msgs, fmt.Sprintf("required header %s is missing", name))
+		}
+	}
+
+	content := JSONContent(documented.Content)
+	if content != nil && len(content.Schema) > 0 {
+		schema, _ := json.Marshal(toJSONSchema(content.Schema))
+
+		result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema), gojsonschema.NewBytesLoader(resp.body))
+		if err != nil {
+			return fmt.Errorf("cannot validate body of operation %s: %s", op.Name(), err)
+		}
+
+		for _, desc := range result.Errors() {
+			msgs = append(msgs, desc.String())
+		}
+	}
+
+	if len(msgs) > 0 {
+		return fmt.Errorf("response does not match operation %s:\n\t%s", op.Name(), strings.Join(msgs, "\n\t"))
+	}
+
+	return nil
+}
+
+func (e OpenAPIExpectation) desc() string {
+	if e.operationID != "" {
+		return fmt.Sprintf("Response matches OpenAPI operation %s (%s)", e.operationID, e.displayName)
+	}
+
+	return fmt.Sprintf("Response matches OpenAPI operation (%s)", e.displayName)
+}
+
 // BodyExpectation validates that expected object is presented in the response.
 // The expected body reflect required part of the response object.
 type BodyExpectation struct {
@@ -250,6 +379,10 @@ func checkAbsentPath(m interface{}, pathItem interface{}) string {
 
 	if pathStr, ok := pathItem.(string); ok {
 
+		if err := validatePath(pathStr); err != nil {
+			return err.Error()
+		}
+
 		searchResult := Search(m, pathStr)
 		if len(searchResult) > 0 {
 			return fmt.Sprintf("Value expected to be absent was found: %v, path: %v", searchResult, pathStr)
@@ -260,3 +393,292 @@ func checkAbsentPath(m interface{}, pathItem interface{}) string {
 
 	return fmt.Sprintf("Path Item: %v is invalid for absence check", pathItem)
 }
+
+// MaxDurationExpectation validates the time spent to receive response.
+type MaxDurationExpectation struct {
+	maxDuration time.Duration
+}
+
+func (e MaxDurationExpectation) check(resp *Response) error {
+	actual := resp.execFrame.Duration()
+	if actual > e.maxDuration {
+		return fmt.Errorf("response time exceeded. Expected: <= %s, Actual: %s", e.maxDuration, actual.Round(time.Millisecond))
+	}
+	return nil
+}
+
+func (e MaxDurationExpectation) desc() string {
+	return fmt.Sprintf("Response time is within %s", e.maxDuration)
+}
+
+// BodySizeExpectation validates length of response body in bytes.
+type BodySizeExpectation struct {
+	min *int64
+	max *int64
+}
+
+func (e BodySizeExpectation) check(resp *Response) error {
+	size := int64(len(resp.body))
+
+	if (e.min != nil && size < *e.min) || (e.max != nil && size > *e.max) {
+		return fmt.Errorf("unexpected body size. Expected: %s, Actual: %d bytes", e.bounds(), size)
+	}
+
+	return nil
+}
+
+func (e BodySizeExpectation) bounds() string {
+	switch {
+	case e.min != nil && e.max != nil:
+		return fmt.Sprintf("%d..%d bytes", *e.min, *e.max)
+	case e.min != nil:
+		return fmt.Sprintf(">= %d bytes", *e.min)
+	case e.max != nil:
+		return fmt.Sprintf("<= %d bytes", *e.max)
+	}
+
+	return "any size"
+}
+
+func (e BodySizeExpectation) desc() string {
+	return fmt.Sprintf("Body size is %s", e.bounds())
+}
+
+// BodySha256Expectation validates SHA-256 checksum (hex) of response body.
+type BodySha256Expectation struct {
+	sum string
+}
+
+func (e BodySha256Expectation) check(resp *Response) error {
+	sum := sha256.Sum256(resp.body)
+	actual := hex.EncodeToString(sum[:])
+
+	if !strings.EqualFold(actual, e.sum) {
+		return fmt.Errorf("unexpected body checksum. Expected: %s, Actual: %s", e.sum, actual)
+	}
+
+	return nil
+}
+
+func (e BodySha256Expectation) desc() string {
+	return fmt.Sprintf("Body SHA-256 is %s", e.sum)
+}
+
+// BodyEqualsFileExpectation validates response body is byte to byte equal to the file.
+type BodyEqualsFileExpectation struct {
+	content     []byte
+	displayName string
+}
+
+func (e BodyEqualsFileExpectation) check(resp *Response) error {
+	if bytes.Equal(resp.body, e.content) {
+		return nil
+	}
+
+	if len(resp.body) != len(e.content) {
+		return fmt.Errorf("body is not equal to %s. Expected: %d bytes, Actual: %d bytes", e.displayName, len(e.content), len(resp.body))
+	}
+
+	for i := range resp.body {
+		if resp.body[i] != e.content[i] {
+			return fmt.Errorf("body is not equal to %s, first difference at byte %d", e.displayName, i)
+		}
+	}
+
+	return nil
+}
+
+func (e BodyEqualsFileExpectation) desc() string {
+	return fmt.Sprintf("Body equals to %s", e.displayName)
+}
+
+// BodyTypeExpectation validates media type detected by the body content (magic number)
+// regardless of the Content-Type header.
+type BodyTypeExpectation struct {
+	mediaType string
+}
+
+func (e BodyTypeExpectation) check(resp *Response) error {
+	actual := sniffMediaType(resp.body)
+
+	if !strings.EqualFold(actual, e.mediaType) {
+		return fmt.Errorf("unexpected body content type. Expected: %s, Actual: %s", e.mediaType, actual)
+	}
+
+	return nil
+}
+
+func (e BodyTypeExpectation) desc() string {
+	return fmt.Sprintf("Body content is '%s'", e.mediaType)
+}
+
+// sniffMediaType detects media type by the first bytes of the content, parameters (e.g. charset) are excluded
+func sniffMediaType(content []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+	return mediaType
+}
+
+// CookieExpectation validates cookie set by the response (Set-Cookie header) and its attributes.
+// Responses of the redirect chain are checked as well.
+type CookieExpectation struct {
+	Name     string
+	Expected CookieExpect
+}
+
+func (e CookieExpectation) check(resp *Response) error {
+	var cookie *http.Cookie
+	for _, c := range chainCookies(resp.http) {
+		if c.Name == e.Name {
+			cookie = c
+		}
+	} // the last one wins like in browsers
+
+	if cookie == nil {
+		return fmt.Errorf("cookie %s is not set", e.Name)
+	}
+
+	exp := e.Expected
+	mismatch := func(attr string, expected, actual interface{}) error {
+		return fmt.Errorf("unexpected %s of cookie %s. Expected: %v, Actual: %v", attr, e.Name, expected, actual)
+	}
+
+	if exp.Value != nil && cookie.Value != *exp.Value {
+		return mismatch("value", *exp.Value, cookie.Value)
+	}
+
+	if exp.Path != "" && cookie.Path != exp.Path {
+		return mismatch("Path", exp.Path, cookie.Path)
+	}
+
+	if exp.Domain != "" && strings.TrimPrefix(cookie.Domain, ".") != strings.TrimPrefix(exp.Domain, ".") {
+		return mismatch("Domain", exp.Domain, cookie.Domain)
+	}
+
+	if exp.Secure != nil && cookie.Secure != *exp.Secure {
+		return mismatch("Secure", *exp.Secure, cookie.Secure)
+	}
+
+	if exp.HTTPOnly != nil && cookie.HttpOnly != *exp.HTTPOnly {
+		return mismatch("HttpOnly", *exp.HTTPOnly, cookie.HttpOnly)
+	}
+
+	if exp.SameSite != "" && !strings.EqualFold(sameSiteName(cookie.SameSite), exp.SameSite) {
+		return mismatch("SameSite", exp.SameSite, sameSiteName(cookie.SameSite))
+	}
+
+	if exp.MaxAge != nil {
+		if cookie.MaxAge == 0 {
+			return fmt.Errorf("Max-Age of cookie %s is not set", e.Name)
+		}
+
+		maxAge := cookie.MaxAge
+		if maxAge < 0 {
+			maxAge = 0
+		} // Max-Age=0 means delete cookie now
+
+		if maxAge != *exp.MaxAge {
+			return mismatch("Max-Age", *exp.MaxAge, maxAge)
+		}
+	}
+
+	return nil
+}
+
+func (e CookieExpectation) desc() string {
+	return fmt.Sprintf("Cookie '%s' is set", e.Name)
+}
+
+// TLSExpectation validates negotiated TLS version and server certificate expiry.
+type TLSExpectation struct {
+	version      uint16
+	minVersion   uint16
+	certValidFor time.Duration
+}
+
+func newTLSExpectation(exp *TLSExpect) (TLSExpectation, error) {
+	e := TLSExpectation{}
+
+	for _, v := range []struct {
+		name  string
+		value *uint16
+	}{{exp.Version, &e.version}, {exp.MinVersion, &e.minVersion}} {
+		if v.name == "" {
+			continue
+		}
+
+		version, ok := tlsVersions[v.name]
+		if !ok {
+			return e, fmt.Errorf("unsupported TLS version %s, expected one of 1.0, 1.1, 1.2, 1.3", v.name)
+		}
+		*v.value = version
+	}
+
+	if exp.CertValidFor != "" {
+		d, err := time.ParseDuration(exp.CertValidFor)
+		if err != nil {
+			return e, fmt.Errorf("invalid certValidFor: %s", exp.CertValidFor)
+		}
+		e.certValidFor = d
+	}
+
+	return e, nil
+}
+
+func (e TLSExpectation) check(resp *Response) error {
+	state := resp.http.TLS
+	if state == nil {
+		return errors.New("response is not received over TLS")
+	}
+
+	if e.version != 0 && state.Version != e.version {
+		return fmt.Errorf("unexpected TLS version. Expected: %s, Actual: %s", tlsVersionName(e.version), tlsVersionName(state.Version))
+	}
+
+	if e.minVersion != 0 && state.Version < e.minVersion {
+		return fmt.Errorf("TLS version %s is lower than %s", tlsVersionName(state.Version), tlsVersionName(e.minVersion))
+	}
+
+	if e.certValidFor != 0 {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("server certificate is not received")
+		}
+
+		notAfter := state.PeerCertificates[0].NotAfter
+		if time.Until(notAfter) < e.certValidFor {
+			return fmt.Errorf("server certificate expires at %s, expected to be valid for at least %s", notAfter.UTC().Format(time.RFC3339), e.certValidFor)
+		}
+	}
+
+	return nil
+}
+
+func (e TLSExpectation) desc() string {
+	return "TLS connection is as expected"
+}
+
+// chainCookies returns cookies set by every response of the redirect chain (e.g. login answering with 302), earlier ones first
+func chainCookies(resp *http.Response) []*http.Cookie {
+	var cookies []*http.Cookie
+	for r := resp; r != nil; {
+		cookies = append(r.Cookies(), cookies...)
+		if r.Request == nil {
+			break
+		}
+		r = r.Request.Response // response which caused the redirect
+	}
+
+	return cookies
+}
+
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+
+	return ""
+}
@@ -1,8 +1,16 @@
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TODO .size() only counts last array not all arrays in search
@@ -285,3 +293,264 @@ func TestCheckAbsentNotExactPath(t *testing.T) {
 		)
 	}
 }
+
+func TestMaxDurationExpectation(t *testing.T) {
+	start := time.Now()
+	resp := &Response{execFrame: TimeFrame{Start: start, End: start.Add(500 * time.Millisecond)}}
+
+	if err := (MaxDurationExpectation{maxDuration: time.Second}).check(resp); err != nil {
+		t.Error("unexpected error", err)
+	}
+
+	err := (MaxDurationExpectation{maxDuration: 300 * time.Millisecond}).check(resp)
+	if err == nil || !strings.Contains(err.Error(), "Actual: 500ms") {
+		t.Error("expected response time error, got", err)
+	}
+}
+
+func TestOpenAPIExpectation(t *testing.T) {
+	spec, err := loadOpenAPISpec(writeTestOpenAPISpec(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := func(method, path string, statusCode int, header http.Header, body string) *Response {
+		req, _ := http.NewRequest(method, "http://example.com"+path, nil)
+		if header == nil {
+			header = http.Header{}
+		}
+		header.Set("Content-Type", "application/json")
+
+		return &Response{http: &http.Response{Request: req, StatusCode: statusCode, Header: header}, body: []byte(body)}
+	}
+
+	tests := []struct {
+		name        string
+		operationID string
+		resp        *Response
+		wantErr     string
+	}{
+		{name: "matches inferred operation", resp: response("GET", "/api/v1/users/42", 200, nil, `{"id": 42, "name": "John", "email": null}`)},
+		{name: "body doesn't match schema", resp: response("GET", "/users/42", 200, nil, `{"id": "42"}`), wantErr: "name is required"},
+		{name: "nested schema reference", resp: response("GET", "/users/42", 200, nil, `{"name": "John", "manager": {"id": "x"}}`), wantErr: "manager.id"},
+		{name: "undocumented status code", resp: response("GET", "/users/42", 500, nil, `{}`), wantErr: "status code 500 is not documented"},
+		{name: "documented status without content", resp: response("GET", "/users/42", 404, nil, `{}`)},
+		{name: "required header is missing", resp: response("POST", "/users", 201, nil, `{"name": "John"}`), wantErr: "required header Location is missing"},
+		{name: "required header is present", resp: response("POST", "/users", 201, http.Header{"Location": {"/users/1"}}, `{"name": "John"}`)},
+		{name: "unknown operation", resp: response("DELETE", "/users/42", 204, nil, ``), wantErr: "no OpenAPI operation matches"},
+		{name: "explicit operation", operationID: "createUser", resp: response("PUT", "/anything", 201, http.Header{"Location": {"/users/1"}}, `{"name": "John"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := OpenAPIExpectation{spec: spec, operationID: tt.operationID}.check(tt.resp)
+
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error %s", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error %#v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestBodySchemaExpectationXML(t *testing.T) {
+	schema := []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+		<xs:element name="user"><xs:complexType><xs:sequence>
+			<xs:element name="id" type="xs:int"/>
+		</xs:sequence></xs:complexType></xs:element>
+	</xs:schema>`)
+
+	exp := BodySchemaExpectation{schema: schema}
+
+	err := exp.check(&Response{
+		http: &http.Response{Header: http.Header{"Content-Type": {"application/xml; charset=utf-8"}}},
+		body: []byte(`<user><id>1</id></user>`),
+	})
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	err = exp.check(&Response{
+		http: &http.Response{Header: http.Header{"Content-Type": {"text/xml"}}},
+		body: []byte(`<user><id>one</id></user>`),
+	})
+	if err == nil || !strings.Contains(err.Error(), "/user/id: value 'one' is not a valid int") {
+		t.Errorf("expected element path in error, got %v", err)
+	}
+}
+
+func TestBodySchemaExpectationXML_RemoteIncludeIsCached(t *testing.T) {
+	initLogger()
+
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		fmt.Fprint(w, `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="id"><xs:restriction base="xs:int"/></xs:simpleType></xs:schema>`)
+	}))
+	defer server.Close()
+
+	exp := BodySchemaExpectation{
+		schema: []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+			<xs:include schemaLocation="types.xsd"/>
+			<xs:element name="id" type="id"/>
+		</xs:schema>`),
+		location: server.URL + "/schemas/user.xsd",
+		fetch:    (&RequestConfig{}).fetch,
+	}
+
+	for i := 0; i < 3; i++ {
+		err := exp.check(&Response{
+			http: &http.Response{Header: http.Header{"Content-Type": {"text/xml"}}},
+			body: []byte(`<id>1</id>`),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+	}
+
+	if hits != 1 {
+		t.Errorf("expected included schema to be loaded once, got %d", hits)
+	}
+}
+
+func TestBinaryBodyExpectations(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n%test\n1 0 obj")
+	resp := &Response{http: &http.Response{Header: http.Header{"Content-Type": {"application/octet-stream"}}}, body: pdf}
+
+	min, max := int64(10), int64(20)
+	dir := t.TempDir()
+	initLogger()
+
+	filePath := filepath.Join(dir, "expected.pdf")
+	if err := ioutil.WriteFile(filePath, pdf, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	otherPath := filepath.Join(dir, "other.pdf")
+	if err := ioutil.WriteFile(otherPath, append([]byte("%PDF-1.5"), pdf[8:]...), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		expect  Expect
+		wantErr string
+	}{
+		{name: "size in range", expect: Expect{BodySize: &BodySize{Min: &min}}},
+		{name: "size out of range", expect: Expect{BodySize: &BodySize{Min: &min, Max: &max}}, wantErr: "Expected: 10..20 bytes, Actual: 22 bytes"},
+		{name: "checksum", expect: Expect{BodySha256: "1F0D59EC4C49522F08A99E9C5144EAAE609AF343F27DAA43A47091CE818B755A"}},
+		{name: "checksum mismatch", expect: Expect{BodySha256: "00"}, wantErr: "unexpected body checksum"},
+		{name: "equals file", expect: Expect{BodyEqualsFile: filePath}},
+		{name: "differs from file", expect: Expect{BodyEqualsFile: otherPath}, wantErr: "first difference at byte 7"},
+		{name: "sniffed type", expect: Expect{BodyType: "application/pdf"}},
+		{name: "sniffed type mismatch", expect: Expect{BodyType: "image/png"}, wantErr: "Expected: image/png, Actual: application/pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exps, err := expectations(tt.expect, "", &RequestConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = exps[0].check(resp)
+
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error %s", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error %#v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCookieExpectation(t *testing.T) {
+	header := http.Header{"Set-Cookie": {
+		"session=abc; Path=/; Domain=example.com; Max-Age=3600; Secure; HttpOnly; SameSite=Strict",
+		"removed=; Max-Age=0",
+		"plain=1",
+	}}
+	resp := &Response{http: &http.Response{Header: header}}
+
+	value, wrongValue := "abc", "xyz"
+	yes, no := true, false
+	hour, zero := 3600, 0
+
+	tests := []struct {
+		name     string
+		cookie   string
+		expected CookieExpect
+		wantErr  string
+	}{
+		{name: "all attributes", cookie: "session", expected: CookieExpect{Value: &value, Path: "/", Domain: ".example.com", Secure: &yes, HTTPOnly: &yes, SameSite: "strict", MaxAge: &hour}},
+		{name: "cookie is set", cookie: "session"},
+		{name: "deleted cookie", cookie: "removed", expected: CookieExpect{MaxAge: &zero, Secure: &no}},
+		{name: "missing cookie", cookie: "other", wantErr: "cookie other is not set"},
+		{name: "wrong value", cookie: "session", expected: CookieExpect{Value: &wrongValue}, wantErr: "unexpected value of cookie session. Expected: xyz, Actual: abc"},
+		{name: "wrong flag", cookie: "session", expected: CookieExpect{HTTPOnly: &no}, wantErr: "unexpected HttpOnly"},
+		{name: "wrong same site", cookie: "session", expected: CookieExpect{SameSite: "Lax"}, wantErr: "Expected: Lax, Actual: Strict"},
+		{name: "max age is not set", cookie: "plain", expected: CookieExpect{MaxAge: &hour}, wantErr: "Max-Age of cookie plain is not set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CookieExpectation{Name: tt.cookie, Expected: tt.expected}.check(resp)
+
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error %s", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error %#v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTLSExpectation(t *testing.T) {
+	notAfter := time.Now().Add(48 * time.Hour)
+	state := &tls.ConnectionState{Version: tls.VersionTLS12, PeerCertificates: []*x509.Certificate{{NotAfter: notAfter}}}
+	resp := &Response{http: &http.Response{TLS: state}}
+
+	tests := []struct {
+		name     string
+		expected TLSExpect
+		resp     *Response
+		wantErr  string
+	}{
+		{name: "all properties", expected: TLSExpect{Version: "1.2", MinVersion: "1.2", CertValidFor: "24h"}, resp: resp},
+		{name: "wrong version", expected: TLSExpect{Version: "1.3"}, resp: resp, wantErr: "unexpected TLS version. Expected: 1.3, Actual: 1.2"},
+		{name: "old version", expected: TLSExpect{MinVersion: "1.3"}, resp: resp, wantErr: "TLS version 1.2 is lower than 1.3"},
+		{name: "expiring certificate", expected: TLSExpect{CertValidFor: "72h"}, resp: resp, wantErr: "expected to be valid for at least 72h0m0s"},
+		{name: "plain http", expected: TLSExpect{Version: "1.2"}, resp: &Response{http: &http.Response{}}, wantErr: "response is not received over TLS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp, err := newTLSExpectation(&tt.expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = exp.check(tt.resp)
+
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error %s", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error %#v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	_, err := newTLSExpectation(&TLSExpect{Version: "2.0"})
+	if err == nil || !strings.Contains(err.Error(), "unsupported TLS version 2.0") {
+		t.Errorf("expected error of unsupported version, got %v", err)
+	}
+}
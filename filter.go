@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TestFilter selects test cases to run by tags and names
+type TestFilter struct {
+	// case should have at least one of the tags
+	Tags []string
+	// case should have none of the tags
+	ExcludeTags []string
+	// matched against "<suite full name>/<case name>"
+	Name *regexp.Regexp
+}
+
+// NewTestFilter creates filter from comma separated tag lists and name pattern
+func NewTestFilter(tags, excludeTags, namePattern string) (*TestFilter, error) {
+	filter := &TestFilter{
+		Tags:        splitTags(tags),
+		ExcludeTags: splitTags(excludeTags),
+	}
+
+	if namePattern != "" {
+		re, err := regexp.Compile(namePattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid test name pattern: %s", err)
+		}
+		filter.Name = re
+	}
+
+	return filter, nil
+}
+
+// SkipReason returns reason the test case is filtered out or empty string if it should be executed.
+func (f *TestFilter) SkipReason(suite TestSuite, testCase TestCase) string {
+	if f == nil {
+		return ""
+	}
+
+	for _, tag := range f.ExcludeTags {
+		if hasTag(testCase.Tags, tag) {
+			return fmt.Sprintf("excluded by tag %s", tag)
+		}
+	}
+
+	if len(f.Tags) > 0 {
+		found := false
+		for _, tag := range f.Tags {
+			if hasTag(testCase.Tags, tag) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Sprintf("has none of tags %s", strings.Join(f.Tags, ","))
+		}
+	}
+
+	if f.Name != nil && !f.Name.MatchString(suite.FullName()+"/"+testCase.Name) {
+		return fmt.Sprintf("name does not match %s", f.Name)
+	}
+
+	return ""
+}
+
+func splitTags(list string) []string {
+	tags := []string{}
+
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergeTags returns union of suite and test case tags
+func mergeTags(suiteTags, caseTags []string) []string {
+	if len(suiteTags) == 0 {
+		return caseTags
+	}
+
+	merged := append([]string{}, suiteTags...)
+	for _, tag := range caseTags {
+		if !hasTag(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+
+	return merged
+}
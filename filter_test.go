@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestTestFilterSkipReason(t *testing.T) {
+	suite := TestSuite{Name: "orders", Dir: "payments"}
+
+	tests := []struct {
+		name     string
+		tags     string
+		exclude  string
+		run      string
+		caseTags []string
+		skipped  bool
+	}{
+		{name: "no filter", skipped: false},
+		{name: "tag matched", tags: "smoke, regression", caseTags: []string{"regression"}, skipped: false},
+		{name: "tag not matched", tags: "smoke", caseTags: []string{"regression"}, skipped: true},
+		{name: "excluded tag wins", tags: "smoke", exclude: "slow", caseTags: []string{"smoke", "slow"}, skipped: true},
+		{name: "name matched", run: "payments.orders/create", skipped: false},
+		{name: "name not matched", run: "^refunds", skipped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewTestFilter(tt.tags, tt.exclude, tt.run)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reason := filter.SkipReason(suite, TestCase{Name: "create order", Tags: tt.caseTags})
+			if (reason != "") != tt.skipped {
+				t.Errorf("unexpected skip reason %#v", reason)
+			}
+		})
+	}
+}
+
+func TestNewTestFilter_InvalidPattern(t *testing.T) {
+	_, err := NewTestFilter("", "", "(")
+	if err == nil {
+		t.Error("error is expected")
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	tags := mergeTags([]string{"payments", "smoke"}, []string{"smoke", "slow"})
+
+	if len(tags) != 3 || tags[0] != "payments" || tags[1] != "smoke" || tags[2] != "slow" {
+		t.Errorf("unexpected tags %v", tags)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var unsafeFileNameRegexp = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// generateSuites builds skeleton test case per OpenAPI operation grouped in suites by tag or path.
+func generateSuites(spec *OpenAPISpec, groupBy string) (map[string][]testCaseDefinition, error) {
+	suites := make(map[string][]testCaseDefinition)
+
+	for _, op := range spec.Operations {
+		call, err := operationCall(op)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", op.Name(), err)
+		}
+
+		group := suiteGroup(op, groupBy)
+		suites[group] = append(suites[group], testCaseDefinition{
+			Name:  op.Name(),
+			Calls: []*callDefinition{call},
+		})
+	}
+
+	return suites, nil
+}
+
+// suiteGroup returns suite name of the operation, e.g. its first tag or first path segment
+func suiteGroup(op *OpenAPIOperation, groupBy string) string {
+	group := ""
+
+	if groupBy == "path" {
+		for _, segment := range strings.Split(op.Path, "/") {
+			if segment != "" && !strings.HasPrefix(segment, "{") {
+				group = segment
+				break
+			}
+		}
+	} else if len(op.Tags) > 0 {
+		group = op.Tags[0]
+	}
+
+	group = strings.Trim(unsafeFileNameRegexp.ReplaceAllString(strings.ToLower(group), "_"), "_")
+	if group == "" {
+		return "default"
+	}
+
+	return group
+}
+
+// operationCall builds call with example request and expected response of the operation
+func operationCall(op *OpenAPIOperation) (*callDefinition, error) {
+	call := &callDefinition{On: On{Method: op.Method}}
+
+	path := op.Path
+	for _, param := range op.Parameters {
+		value := exampleValue(param.Schema, param.Example)
+
+		switch param.In {
+		case "path":
+			path = strings.Replace(path, "{"+param.Name+"}", url.PathEscape(toString(value)), -1)
+		case "query":
+			if !param.Required {
+				continue
+			}
+			if call.On.Params == nil {
+				call.On.Params = make(map[string]string)
+			}
+			call.On.Params[param.Name] = toString(value)
+		case "header":
+			if !param.Required {
+				continue
+			}
+			if call.On.Headers == nil {
+				call.On.Headers = make(map[string]string)
+			}
+			call.On.Headers[param.Name] = toString(value)
+		}
+	}
+	call.On.URL = path
+
+	if op.RequestBody != nil {
+		if content := JSONContent(op.RequestBody.Content); content != nil {
+			body, err := json.Marshal(exampleValue(content.Schema, content.Example))
+			if err != nil {
+				return nil, err
+			}
+
+			call.On.Body = body
+			call.On.Headers = withHeader(call.On.Headers, "Content-Type", "application/json")
+		}
+	}
+
+	statusCode := op.SuccessStatusCode()
+	call.Expect.StatusCode = &statusCode
+
+	if resp, _ := op.Response(statusCode); resp != nil {
+		if content := JSONContent(resp.Content); content != nil && len(content.Schema) > 0 {
+			schema, err := json.Marshal(toJSONSchema(content.Schema))
+			if err != nil {
+				return nil, err
+			}
+
+			call.Expect.ContentType = "application/json"
+			call.Expect.BodySchemaRaw = schema
+		}
+	}
+
+	return call, nil
+}
+
+func withHeader(headers map[string]string, key, value string) map[string]string {
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+
+	if _, ok := headers[key]; !ok {
+		headers[key] = value
+	}
+
+	return headers
+}
+
+// generateCommand writes skeleton suites generated from OpenAPI spec
+func generateCommand(args []string) {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	specPath := flags.String("openapi", "", "OpenAPI 3 spec file (json or yaml)")
+	out := flags.String("out", ".", "Directory to write suite files to")
+	groupBy := flags.String("group-by", "tag", "Suite per operation 'tag' or first 'path' segment")
+	flags.Usage = func() {
+		h := "Usage:\n"
+		h += "  bozr generate --openapi FILE [OPTIONS]\n\n"
+
+		h += "Options:\n"
+		h += "      --openapi   OpenAPI 3 spec file (json or yaml)\n"
+		h += "      --out       Directory to write suite files to. Default is current directory\n"
+		h += "      --group-by  Suite per operation 'tag' or first 'path' segment. Default is tag\n"
+
+		fmt.Fprint(os.Stderr, h)
+	}
+
+	flags.Parse(args)
+
+	initLogger()
+
+	if *specPath == "" || (*groupBy != "tag" && *groupBy != "path") {
+		flags.Usage()
+		fmt.Println()
+		terminate(exitCodeRuntimeError, "You must specify OpenAPI spec file and valid grouping.")
+		return
+	}
+
+	spec, err := loadOpenAPISpec(*specPath)
+	if err != nil {
+		terminate(exitCodeRuntimeError, err.Error())
+		return
+	}
+
+	suites, err := generateSuites(spec, *groupBy)
+	if err != nil {
+		terminate(exitCodeRuntimeError, err.Error())
+		return
+	}
+
+	names := make([]string, 0, len(suites))
+	for name := range suites {
+		names = append(names, name)
+
+		path := filepath.Join(*out, name+suiteExts[0])
+		if _, err := os.Stat(path); err == nil {
+			terminate(exitCodeRuntimeError, fmt.Sprintf("Suite %s already exists.", path))
+			return
+		}
+	}
+	sort.Strings(names)
+
+	err = os.MkdirAll(*out, 0777)
+	if err != nil {
+		terminate(exitCodeRuntimeError, err.Error())
+		return
+	}
+
+	for _, name := range names {
+		path := filepath.Join(*out, name+suiteExts[0])
+
+		content, err := json.MarshalIndent(suites[name], "", "  ")
+		if err != nil {
+			terminate(exitCodeRuntimeError, err.Error())
+			return
+		}
+
+		err = ioutil.WriteFile(path, content, 0666)
+		if err != nil {
+			terminate(exitCodeRuntimeError, err.Error())
+			return
+		}
+
+		fmt.Printf("%s (%d test cases)\n", path, len(suites[name]))
+	}
+}
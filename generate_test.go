@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateSuites(t *testing.T) {
+	// given
+	initLogger()
+
+	spec, err := loadOpenAPISpec(writeTestOpenAPISpec(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// when
+	suites, err := generateSuites(spec, "tag")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// then
+	if len(suites["users"]) != 2 || len(suites["default"]) != 1 {
+		t.Fatalf("unexpected suites %v", suites)
+	}
+
+	getUser := suites["users"][1].Calls[0]
+	if getUser.On.URL != "/users/42" || getUser.On.Params["fields"] != "name" {
+		t.Errorf("unexpected request %+v", getUser.On)
+	}
+
+	if *getUser.Expect.StatusCode != 200 || len(getUser.Expect.BodySchemaRaw) == 0 {
+		t.Errorf("unexpected expectations %+v", getUser.Expect)
+	}
+
+	createUser := suites["users"][0].Calls[0]
+	if *createUser.Expect.StatusCode != 201 || createUser.On.Headers["Content-Type"] != "application/json" {
+		t.Errorf("unexpected create call %+v", createUser)
+	}
+
+	dir := t.TempDir()
+	for name, cases := range suites {
+		content, _ := json.MarshalIndent(cases, "", "  ")
+		ioutil.WriteFile(filepath.Join(dir, name+".suite.json"), content, 0666)
+	}
+
+	err = ValidateSuites(dir, suiteExts, ignoredSuiteExts)
+	if err != nil {
+		t.Errorf("generated suites are invalid: %s", err)
+	}
+}
+
+func TestSuiteGroup(t *testing.T) {
+	op := &OpenAPIOperation{Path: "/{tenant}/order-items/{id}", Tags: []string{"Order Items"}}
+
+	if group := suiteGroup(op, "tag"); group != "order_items" {
+		t.Errorf("unexpected tag group %s", group)
+	}
+
+	if group := suiteGroup(op, "path"); group != "order-items" {
+		t.Errorf("unexpected path group %s", group)
+	}
+}
@@ -10,6 +10,7 @@ require (
 	github.com/pkg/errors v0.9.1
 	github.com/xeipuuv/gojsonschema v1.2.0
 	golang.org/x/sys v0.0.0-20210823070655-63515b42dcdf // indirect
+	gopkg.in/yaml.v3 v3.0.1
 	moul.io/http2curl v1.0.0
 )
 
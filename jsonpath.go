@@ -0,0 +1,576 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONPath expressions are opt-in alternative to the dot-separated paths.
+// Path starting with '$' is treated as JSONPath, e.g. $.users[?(@.email == 'john@example.com')].id
+//
+// Supported syntax:
+//   - $.key, $['key']        child
+//   - $.*, $[*]              all children of object or array
+//   - $..key                 recursive descent
+//   - $[0], $[-1], $[0,2]    index (negative counts from the end), union
+//   - $[1:3], $[::2]         slice
+//   - $[?(@.age > 18)]       filter with ==, !=, <, <=, >, >=, &&, || and existence check $[?(@.email)]
+const jsonPathRoot = "$"
+
+// IsJSONPath checks whether path is a JSONPath expression.
+// Dot paths with the first key starting with $ (e.g. $ref.id) are not.
+func IsJSONPath(pathLine string) bool {
+	return pathLine == jsonPathRoot || strings.HasPrefix(pathLine, jsonPathRoot+".") || strings.HasPrefix(pathLine, jsonPathRoot+"[")
+}
+
+// jsonPathSelector selects child nodes of the given node
+type jsonPathSelector func(node interface{}) []interface{}
+
+type jsonPathSegment struct {
+	recursive bool
+	selector  jsonPathSelector
+}
+
+// JSONPath is a compiled JSONPath expression
+type JSONPath struct {
+	expr     string
+	segments []jsonPathSegment
+}
+
+// CompileJSONPath parses JSONPath expression
+func CompileJSONPath(expr string) (*JSONPath, error) {
+	if !IsJSONPath(expr) {
+		return nil, fmt.Errorf("JSONPath must start with '$': %s", expr)
+	}
+
+	p := &JSONPath{expr: expr}
+	rest := expr[len(jsonPathRoot):]
+
+	for len(rest) > 0 {
+		recursive := false
+		if strings.HasPrefix(rest, "..") {
+			recursive = true
+			rest = rest[2:]
+		} else if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+		} else if !strings.HasPrefix(rest, "[") {
+			return nil, fmt.Errorf("invalid JSONPath %s: unexpected '%s'", expr, rest)
+		}
+
+		var (
+			selector jsonPathSelector
+			err      error
+		)
+
+		if strings.HasPrefix(rest, "[") {
+			end := closingBracket(rest)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %s: missing ']'", expr)
+			}
+
+			selector, err = bracketSelector(rest[1:end])
+			rest = rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			selector, err = nameSelector(rest[:end])
+			rest = rest[end:]
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSONPath %s: %s", expr, err)
+		}
+
+		p.segments = append(p.segments, jsonPathSegment{recursive: recursive, selector: selector})
+	}
+
+	return p, nil
+}
+
+// Search returns all values matching the expression
+func (p *JSONPath) Search(root interface{}) []interface{} {
+	nodes := []interface{}{root}
+
+	for _, segment := range p.segments {
+		next := make([]interface{}, 0)
+
+		for _, node := range nodes {
+			if !segment.recursive {
+				next = append(next, segment.selector(node)...)
+				continue
+			}
+
+			for _, descendant := range descendants(node) {
+				next = append(next, segment.selector(descendant)...)
+			}
+		}
+
+		nodes = next
+	}
+
+	return nodes
+}
+
+// descendants returns node itself and all nested nodes in document order
+func descendants(node interface{}) []interface{} {
+	result := []interface{}{node}
+
+	for _, child := range children(node) {
+		result = append(result, descendants(child)...)
+	}
+
+	return result
+}
+
+// children returns values of an object (sorted by key) or items of an array
+func children(node interface{}) []interface{} {
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typed))
+		for k := range typed {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		result := make([]interface{}, 0, len(typed))
+		for _, k := range keys {
+			result = append(result, typed[k])
+		}
+		return result
+
+	case []interface{}:
+		return typed
+	}
+
+	return nil
+}
+
+func nameSelector(name string) (jsonPathSelector, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty name")
+	}
+
+	if name == "*" {
+		return children, nil
+	}
+
+	if transform, ok := pathTransforms[name]; ok {
+		return func(node interface{}) []interface{} {
+			transformed, err := transform(node)
+			if err != nil {
+				debugf("%s", err)
+				return nil
+			}
+			return []interface{}{transformed}
+		}, nil
+	} // e.g. $.access_token.jwt().sub
+
+	if strings.ContainsAny(name, " \t=<>!()'\"") {
+		return nil, fmt.Errorf("invalid name '%s', use ['...'] notation", name)
+	}
+
+	return func(node interface{}) []interface{} {
+		if m, ok := node.(map[string]interface{}); ok {
+			if v, ok := m[name]; ok {
+				return []interface{}{v}
+			}
+		}
+		return nil
+	}, nil
+}
+
+func bracketSelector(content string) (jsonPathSelector, error) {
+	content = strings.TrimSpace(content)
+
+	switch {
+	case content == "*":
+		return children, nil
+
+	case strings.HasPrefix(content, "?"):
+		return filterSelector(strings.TrimSpace(content[1:]))
+
+	case strings.HasPrefix(content, "'") || strings.HasPrefix(content, "\""):
+		var names []string
+		for _, part := range splitOutsideQuotes(content, ",") {
+			name, err := unquote(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+		}
+		return func(node interface{}) []interface{} {
+			var result []interface{}
+			if m, ok := node.(map[string]interface{}); ok {
+				for _, name := range names {
+					if v, ok := m[name]; ok {
+						result = append(result, v)
+					}
+				}
+			}
+			return result
+		}, nil
+
+	case strings.Contains(content, ":"):
+		return sliceSelector(content)
+
+	default:
+		var indexes []int
+		for _, part := range strings.Split(content, ",") {
+			idx, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return nil, fmt.Errorf("invalid index '%s'", part)
+			}
+			indexes = append(indexes, idx)
+		}
+		return func(node interface{}) []interface{} {
+			var result []interface{}
+			if arr, ok := node.([]interface{}); ok {
+				for _, idx := range indexes {
+					if idx < 0 {
+						idx = len(arr) + idx
+					}
+					if idx >= 0 && idx < len(arr) {
+						result = append(result, arr[idx])
+					}
+				}
+			}
+			return result
+		}, nil
+	}
+}
+
+func sliceSelector(content string) (jsonPathSelector, error) {
+	parts := strings.Split(content, ":")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("invalid slice '%s'", content)
+	}
+
+	bounds := make([]*int, 3)
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid slice '%s'", content)
+		}
+		bounds[i] = &v
+	}
+
+	step := 1
+	if bounds[2] != nil {
+		step = *bounds[2]
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("slice step must be positive '%s'", content)
+	}
+
+	return func(node interface{}) []interface{} {
+		arr, ok := node.([]interface{})
+		if !ok {
+			return nil
+		}
+
+		normalize := func(b *int, def int) int {
+			if b == nil {
+				return def
+			}
+			v := *b
+			if v < 0 {
+				v = len(arr) + v
+			}
+			if v < 0 {
+				v = 0
+			}
+			if v > len(arr) {
+				v = len(arr)
+			}
+			return v
+		}
+
+		var result []interface{}
+		for i := normalize(bounds[0], 0); i < normalize(bounds[1], len(arr)); i += step {
+			result = append(result, arr[i])
+		}
+		return result
+	}, nil
+}
+
+// filterSelector selects children matching filter expression, e.g. (@.price < 10 && @.available)
+func filterSelector(expr string) (jsonPathSelector, error) {
+	if !strings.HasPrefix(expr, "(") || !strings.HasSuffix(expr, ")") {
+		return nil, fmt.Errorf("filter must be enclosed in parentheses '%s'", expr)
+	}
+
+	predicate, err := compileFilter(expr[1 : len(expr)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	return func(node interface{}) []interface{} {
+		var result []interface{}
+		for _, child := range children(node) {
+			if predicate(child) {
+				result = append(result, child)
+			}
+		}
+		return result
+	}, nil
+}
+
+type jsonPathPredicate func(node interface{}) bool
+
+var filterOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func compileFilter(expr string) (jsonPathPredicate, error) {
+	if ors := splitOutsideQuotes(expr, "||"); len(ors) > 1 {
+		var predicates []jsonPathPredicate
+		for _, or := range ors {
+			p, err := compileFilter(or)
+			if err != nil {
+				return nil, err
+			}
+			predicates = append(predicates, p)
+		}
+		return func(node interface{}) bool {
+			for _, p := range predicates {
+				if p(node) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	if ands := splitOutsideQuotes(expr, "&&"); len(ands) > 1 {
+		var predicates []jsonPathPredicate
+		for _, and := range ands {
+			p, err := compileFilter(and)
+			if err != nil {
+				return nil, err
+			}
+			predicates = append(predicates, p)
+		}
+		return func(node interface{}) bool {
+			for _, p := range predicates {
+				if !p(node) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	}
+
+	expr = strings.TrimSpace(expr)
+
+	for _, op := range filterOperators {
+		parts := splitOutsideQuotes(expr, op)
+		if len(parts) != 2 {
+			continue
+		}
+
+		left, err := compileOperand(parts[0])
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := compileOperand(parts[1])
+		if err != nil {
+			return nil, err
+		}
+
+		return func(node interface{}) bool {
+			l, lok := left(node)
+			r, rok := right(node)
+			return lok && rok && compareValues(l, r, op)
+		}, nil
+	}
+
+	operand, err := compileOperand(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(node interface{}) bool {
+		_, ok := operand(node)
+		return ok
+	}, nil // existence check
+}
+
+type jsonPathOperand func(node interface{}) (interface{}, bool)
+
+func compileOperand(expr string) (jsonPathOperand, error) {
+	expr = strings.TrimSpace(expr)
+
+	if strings.HasPrefix(expr, "@") {
+		rel, err := CompileJSONPath(jsonPathRoot + expr[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		return func(node interface{}) (interface{}, bool) {
+			res := rel.Search(node)
+			if len(res) == 0 {
+				return nil, false
+			}
+			return res[0], true
+		}, nil
+	}
+
+	var value interface{}
+	switch {
+	case strings.HasPrefix(expr, "'") || strings.HasPrefix(expr, "\""):
+		str, err := unquote(expr)
+		if err != nil {
+			return nil, err
+		}
+		value = str
+	case expr == "true":
+		value = true
+	case expr == "false":
+		value = false
+	case expr == "null":
+		value = nil
+	default:
+		num, err := strconv.ParseFloat(expr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter operand '%s'", expr)
+		}
+		value = num
+	}
+
+	return func(node interface{}) (interface{}, bool) {
+		return value, true
+	}, nil
+}
+
+func compareValues(left, right interface{}, op string) bool {
+	switch op {
+	case "==":
+		return reflect.DeepEqual(left, right)
+	case "!=":
+		return !reflect.DeepEqual(left, right)
+	}
+
+	if lf, ok := left.(float64); ok {
+		if rf, ok := right.(float64); ok {
+			switch op {
+			case "<":
+				return lf < rf
+			case "<=":
+				return lf <= rf
+			case ">":
+				return lf > rf
+			case ">=":
+				return lf >= rf
+			}
+		}
+	}
+
+	if ls, ok := left.(string); ok {
+		if rs, ok := right.(string); ok {
+			switch op {
+			case "<":
+				return ls < rs
+			case "<=":
+				return ls <= rs
+			case ">":
+				return ls > rs
+			case ">=":
+				return ls >= rs
+			}
+		}
+	}
+
+	return false
+}
+
+// closingBracket returns position of ']' matching '[' at the start of the string
+func closingBracket(s string) int {
+	depth := 0
+	var quote rune
+
+	for i, r := range s {
+		if quote != 0 {
+			if r == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch r {
+		case '\'', '"':
+			quote = r
+		case '[', '(':
+			depth++
+		case ']', ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// splitOutsideQuotes splits string by separator ignoring separators inside of quoted strings and brackets
+func splitOutsideQuotes(s, sep string) []string {
+	var (
+		parts []string
+		quote byte
+		depth int
+		start int
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"':
+			quote = c
+			continue
+		case '[', '(':
+			depth++
+			continue
+		case ']', ')':
+			depth--
+			continue
+		}
+
+		if depth == 0 && strings.HasPrefix(s[i:], sep) {
+			// '<' must not match '<=' etc.
+			if len(sep) == 1 && i+1 < len(s) && s[i+1] == '=' {
+				continue
+			}
+			parts = append(parts, s[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != s[len(s)-1] || (s[0] != '\'' && s[0] != '"') {
+		return "", fmt.Errorf("invalid quoted string %s", s)
+	}
+
+	return s[1 : len(s)-1], nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const jsonPathTestDoc = `{
+	"users": [
+		{"id": 1, "email": "john@example.com", "age": 38, "roles": ["admin"]},
+		{"id": 2, "email": "jane@example.com", "age": 12},
+		{"id": 3, "email": "bob@example.com", "age": 21, "roles": []}
+	],
+	"meta": {"total": 3, "page": {"id": "p1"}}
+}`
+
+func TestJSONPathSearch(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(jsonPathTestDoc), &doc)
+
+	tests := []struct {
+		path     string
+		expected []interface{}
+	}{
+		{path: "$.meta.total", expected: []interface{}{3.0}},
+		{path: "$['meta']['total']", expected: []interface{}{3.0}},
+		{path: "$.users[0].id", expected: []interface{}{1.0}},
+		{path: "$.users[-1].id", expected: []interface{}{3.0}},
+		{path: "$.users[0,2].id", expected: []interface{}{1.0, 3.0}},
+		{path: "$.users[1:].id", expected: []interface{}{2.0, 3.0}},
+		{path: "$.users[::2].id", expected: []interface{}{1.0, 3.0}},
+		{path: "$.users[*].id", expected: []interface{}{1.0, 2.0, 3.0}},
+		{path: "$.meta.*", expected: []interface{}{map[string]interface{}{"id": "p1"}, 3.0}},
+		{path: "$..id", expected: []interface{}{"p1", 1.0, 2.0, 3.0}},
+		{path: "$.users[?(@.email == 'jane@example.com')].id", expected: []interface{}{2.0}},
+		{path: "$.users[?(@.age >= 21 && @.roles)].id", expected: []interface{}{1.0, 3.0}},
+		{path: "$.users[?(@.age < 18 || @.id == 1)].id", expected: []interface{}{1.0, 2.0}},
+		{path: "$.users[?(@.roles[0] == 'admin')].email", expected: []interface{}{"john@example.com"}},
+		{path: "$.missing", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			p, err := CompileJSONPath(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := p.Search(doc)
+			if len(actual) == 0 && len(tt.expected) == 0 {
+				return
+			}
+
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("Expected: %v, Actual: %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestJSONPathInvalid(t *testing.T) {
+	for _, path := range []string{"$.users[", "$.users[?(@.id === )]", "$.users[a]", "$users", "$.users[::0]"} {
+		if _, err := CompileJSONPath(path); err == nil {
+			t.Errorf("expected error for %s", path)
+		}
+	}
+}
+
+func TestIsJSONPath(t *testing.T) {
+	for path, expected := range map[string]bool{"$": true, "$.users": true, "$['users']": true, "$..id": true, "$ref.id": false, "$type": false, "users": false} {
+		if IsJSONPath(path) != expected {
+			t.Errorf("unexpected result for %s, expected %v", path, expected)
+		}
+	}
+
+	body := map[string]interface{}{"$ref": map[string]interface{}{"id": "x"}}
+	if value, err := GetByPath(body, "$ref.id"); err != nil || value != "x" {
+		t.Errorf("unexpected value of dot path starting with $: %v, %v", value, err)
+	}
+}
+
+func TestJSONPathInMatchers(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(jsonPathTestDoc), &doc)
+
+	id, err := GetByPath(doc, "$.users[?(@.email == 'bob@example.com')].id")
+	if err != nil || id != 3.0 {
+		t.Error("unexpected remembered value", id, err)
+	}
+
+	err = SearchByPath(doc, 3.0, "$.users[*].size()")
+	if err == nil {
+		t.Error("size() requires exactly one result")
+	}
+
+	err = SearchByPath(doc, 3.0, "$.users.size()")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if msg := checkAbsentPath(doc, "$.users[?(@.password)]"); msg != "" {
+		t.Error(msg)
+	}
+
+	if msg := checkAbsentPath(doc, "$.users[?(@.id =)]"); msg == "" {
+		t.Error("invalid path should be reported")
+	}
+}
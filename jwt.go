@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// signJWT builds compact JWT signed with HS256 (key is a secret) or RS256 (key is PEM private key).
+// Claims are name and value pairs, exp is set relative to now if expiresIn is not empty, iat is always set.
+func signJWT(alg string, key []byte, expiresIn string, claims []interface{}) (string, error) {
+	if len(claims)%2 != 0 {
+		return "", errors.New("claims must be name and value pairs")
+	}
+
+	now := time.Now()
+	payload := map[string]interface{}{"iat": now.Unix()}
+
+	if expiresIn != "" {
+		d, err := time.ParseDuration(expiresIn)
+		if err != nil {
+			return "", fmt.Errorf("invalid expiration: %s", expiresIn)
+		}
+		payload["exp"] = now.Add(d).Unix()
+	}
+
+	for i := 0; i < len(claims); i += 2 {
+		name, ok := claims[i].(string)
+		if !ok {
+			return "", fmt.Errorf("claim name must be a string: %v", claims[i])
+		}
+		payload[name] = claims[i+1]
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+
+	var signature []byte
+	switch alg {
+	case "HS256":
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+
+	case "RS256":
+		privateKey, err := parseRSAPrivateKey(key)
+		if err != nil {
+			return "", err
+		}
+
+		sum := sha256.Sum256([]byte(signingInput))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, sum[:])
+		if err != nil {
+			return "", err
+		}
+
+	default:
+		return "", fmt.Errorf("unsupported JWT algorithm %s", alg)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey reads PKCS#1 or PKCS#8 PEM encoded key
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %s", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not RSA")
+	}
+
+	return rsaKey, nil
+}
+
+// readJWTKey reads key file, path is relative to the suite
+func readJWTKey(suitePath, path string) ([]byte, error) {
+	abs, err := toAbsPath(suitePath, path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ioutil.ReadFile(abs)
+	if err != nil {
+		return nil, fmt.Errorf("can't read key file: %s", err.Error())
+	}
+
+	return key, nil
+}
+
+// decodeJWT returns decoded header (part 0) or claims (part 1) of the token, signature is not verified
+func decodeJWT(token interface{}, part int) (map[string]interface{}, error) {
+	str, ok := token.(string)
+	if !ok {
+		return nil, fmt.Errorf("JWT is expected to be a string, got %#v", token)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(str), "Bearer "), ".")
+	if len(parts) != 3 {
+		return nil, errors.New("JWT must consist of 3 parts separated by dots")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[part], "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT encoding: %s", err)
+	}
+
+	decoded := make(map[string]interface{})
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT: %s", err)
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJWTHS256Template(t *testing.T) {
+	vars := NewVars("")
+	vars.Add("secret", "s3cr3t")
+
+	tmplCtx := NewTemplateContext(vars)
+	token := tmplCtx.ApplyTo("{{ .JWTHS256 `{secret}` `1h` `sub` `john` `admin` true `level` 3 }}")
+	if tmplCtx.HasErrors() {
+		t.Fatal(tmplCtx.Error())
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("unexpected token %s", token)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) != parts[2] {
+		t.Error("invalid signature")
+	}
+
+	claims, err := decodeJWT(token, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims["sub"] != "john" || claims["admin"] != true || claims["level"] != float64(3) {
+		t.Errorf("unexpected claims %v", claims)
+	}
+
+	exp, _ := claims["exp"].(float64)
+	if delta := int64(exp) - time.Now().Add(time.Hour).Unix(); delta < -5 || delta > 5 {
+		t.Errorf("unexpected expiration %v", claims["exp"])
+	}
+
+	header, _ := decodeJWT(token, 0)
+	if header["alg"] != "HS256" {
+		t.Errorf("unexpected header %v", header)
+	}
+}
+
+func TestJWTRS256Template(t *testing.T) {
+	initLogger()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	err = ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmplCtx := NewTemplateContext(NewVars(""))
+	token := tmplCtx.ApplyTo("{{ .JWTRS256 `" + keyPath + "` `` `sub` `john` }}")
+	if tmplCtx.HasErrors() {
+		t.Fatal(tmplCtx.Error())
+	}
+
+	parts := strings.Split(token, ".")
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], signature)
+	if err != nil {
+		t.Errorf("invalid signature: %s", err)
+	}
+
+	claims, _ := decodeJWT(token, 1)
+	if _, ok := claims["exp"]; ok {
+		t.Errorf("expiration is not expected %v", claims)
+	}
+}
+
+func TestJWTRS256Template_KeyRelativeToSuite(t *testing.T) {
+	initLogger()
+
+	root := t.TempDir()
+	os.Mkdir(filepath.Join(root, "auth"), 0755)
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	err := ioutil.WriteFile(filepath.Join(root, "auth", "key.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(dir string) { suitesDir = dir }(suitesDir)
+	suitesDir = root
+
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}))
+	defer server.Close()
+
+	suite := TestSuite{
+		Dir: "auth",
+		Cases: []TestCase{{Name: "signed", Calls: []Call{{
+			On: On{Method: "GET", URL: server.URL, Headers: map[string]string{"Authorization": "Bearer {{ .JWTRS256 `key.pem` `` `sub` `john` }}"}},
+		}}}},
+	}
+
+	results := runSuite(&RunConfig{requestConfig: &RequestConfig{}, rewriteConfig: &RewriteConfig{}}, suite)
+
+	if results[0].hasError() {
+		t.Fatal(results[0].Error())
+	}
+
+	claims, err := decodeJWT(token, 1)
+	if err != nil || claims["sub"] != "john" {
+		t.Errorf("unexpected token %s, %v", token, err)
+	}
+}
+
+func TestJWTTemplateErrors(t *testing.T) {
+	tmplCtx := NewTemplateContext(NewVars(""))
+	tmplCtx.ApplyTo("{{ .JWTHS256 `secret` `1h` `sub` }}")
+
+	if !tmplCtx.HasErrors() || !strings.Contains(tmplCtx.Error().Error(), "name and value pairs") {
+		t.Errorf("expected error of odd claims, got %v", tmplCtx.Error())
+	}
+}
+
+func TestJWTPathFunc(t *testing.T) {
+	token, err := signJWT("HS256", []byte("secret"), "", []interface{}{"sub", "john", "roles", []string{"admin", "user"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := map[string]interface{}{"access_token": token, "items": []interface{}{map[string]interface{}{"token": token}}, "invalid": "abc"}
+
+	sub, err := GetByPath(body, "access_token.jwt().sub")
+	if err != nil || sub != "john" {
+		t.Errorf("unexpected claim %v, %v", sub, err)
+	}
+
+	claims, err := GetByPath(body, "access_token.jwt()")
+	if _, ok := claims.(map[string]interface{}); err != nil || !ok {
+		t.Errorf("unexpected claims %v, %v", claims, err)
+	}
+
+	alg, err := GetByPath(body, "access_token.jwtHeader().alg")
+	if err != nil || alg != "HS256" {
+		t.Errorf("unexpected header %v, %v", alg, err)
+	}
+
+	if err := SearchByPath(body, "admin", "items.token.jwt().roles"); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := GetByPath(body, "invalid.jwt().sub"); err == nil {
+		t.Error("expected error for invalid token")
+	}
+
+	sub, err = GetByPath(body, "$.access_token.jwt().sub")
+	if err != nil || sub != "john" {
+		t.Errorf("unexpected JSONPath claim %v, %v", sub, err)
+	}
+
+	if err := SearchByPath(body, "admin", "$.items[*].token.jwt().roles"); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := GetByPath(body, "$.invalid.jwt().sub"); err == nil {
+		t.Error("expected error for invalid token in JSONPath")
+	}
+}
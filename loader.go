@@ -7,9 +7,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
 )
 
 // SuiteFile describes location of the suite file.
@@ -52,38 +54,68 @@ func (sf SuiteFile) ToSuite() *TestSuite {
 		return nil
 	}
 
-	content, e := ioutil.ReadFile(path)
+	content, e := readSuiteFile(path)
 
 	if e != nil {
 		fmt.Println("Cannot read file:", path, "Error: ", e.Error())
 		return nil
 	}
 
-	var rawCases []*TestCase
-	err = json.Unmarshal(content, &rawCases)
+	var def suiteDefinition
+	err = def.UnmarshalJSON(content)
 	if err != nil {
 		fmt.Println("Cannot parse file:", path, "Error: ", err.Error())
 		return nil
 	}
 
 	var cases []TestCase
-	for _, tc := range rawCases {
+	for _, tc := range def.Tests {
 		if sf.Ignored {
 			msg := "Ignored suite"
 			tc.Ignore = &msg
 		}
+		tc.Tags = mergeTags(def.Tags, tc.Tags)
 		cases = append(cases, *tc)
 	}
 
 	su := TestSuite{
-		Name:  strings.TrimSuffix(info.Name(), sf.Ext),
-		Dir:   sf.RelDir(),
-		Cases: cases,
+		Name:       strings.TrimSuffix(info.Name(), sf.Ext),
+		Dir:        sf.RelDir(),
+		Cases:      cases,
+		BeforeAll:  def.BeforeAll,
+		AfterAll:   def.AfterAll,
+		BeforeEach: def.BeforeEach,
+		AfterEach:  def.AfterEach,
+
+		MaxDuration: def.MaxDuration,
 	}
 
 	return &su
 }
 
+// suiteDefinition is a serialized form of the suite.
+// Suite file is either an array of test cases or an object with test cases and setup/teardown calls.
+type suiteDefinition struct {
+	BeforeAll  []Call `json:"beforeAll"`
+	AfterAll   []Call `json:"afterAll"`
+	BeforeEach []Call `json:"beforeEach"`
+	AfterEach  []Call `json:"afterEach"`
+	// default latency budget of every call in the suite
+	MaxDuration string `json:"maxDuration"`
+	// tags applied to every test case in the suite
+	Tags  []string    `json:"tags"`
+	Tests []*TestCase `json:"tests"`
+}
+
+func (def *suiteDefinition) UnmarshalJSON(content []byte) error {
+	if strings.HasPrefix(strings.TrimSpace(string(content)), "[") {
+		return json.Unmarshal(content, &def.Tests)
+	}
+
+	type plain suiteDefinition // avoid recursion
+	return json.Unmarshal(content, (*plain)(def))
+}
+
 // SuiteFileIterator is an interface to iterate over a set of suite files
 // in a given directory
 type SuiteFileIterator interface {
@@ -93,9 +125,9 @@ type SuiteFileIterator interface {
 
 // DirSuiteFileIterator iterates over all suite files inside of specified root folder.
 type DirSuiteFileIterator struct {
-	RootDir   string
-	SuiteExt  string
-	XSuiteExt string
+	RootDir    string
+	SuiteExts  []string
+	XSuiteExts []string
 
 	files []SuiteFile
 	pos   int
@@ -117,17 +149,16 @@ func (ds *DirSuiteFileIterator) addSuiteFile(path string, info os.FileInfo, err
 
 	fileName := info.Name()
 
-	isSuite := strings.HasSuffix(fileName, ds.SuiteExt)
-	isXSuite := strings.HasSuffix(fileName, ds.XSuiteExt)
+	ext, isSuite := matchExt(fileName, ds.SuiteExts)
+	xext, isXSuite := matchExt(fileName, ds.XSuiteExts)
 
 	if !(isSuite || isXSuite) {
 		debug.Printf("Skipping non-suite file: %s\n", fileName)
 		return nil
 	}
 
-	ext := ds.SuiteExt
 	if isXSuite {
-		ext = ds.XSuiteExt
+		ext = xext
 	}
 
 	ds.files = append(ds.files, SuiteFile{
@@ -140,6 +171,17 @@ func (ds *DirSuiteFileIterator) addSuiteFile(path string, info os.FileInfo, err
 	return nil
 }
 
+// matchExt returns the first extension from the list the file name ends with.
+func matchExt(fileName string, exts []string) (string, bool) {
+	for _, ext := range exts {
+		if strings.HasSuffix(fileName, ext) {
+			return ext, true
+		}
+	}
+
+	return "", false
+}
+
 // HasNext returns true in case there is at least one more suite.
 func (ds *DirSuiteFileIterator) HasNext() bool {
 	return len(ds.files) > ds.pos
@@ -157,10 +199,11 @@ func (ds *DirSuiteFileIterator) Next() *SuiteFile {
 }
 
 // NewSuiteLoader returns channel of suites that are read from specified folder.
-func NewSuiteLoader(rootDir, suiteExt, xsuiteExt string) <-chan TestSuite {
+// Loading is stopped once stop channel is closed.
+func NewSuiteLoader(rootDir string, suiteExts, xsuiteExts []string, stop <-chan struct{}) <-chan TestSuite {
 	channel := make(chan TestSuite)
 
-	source := &DirSuiteFileIterator{RootDir: rootDir, SuiteExt: suiteExt, XSuiteExt: xsuiteExt}
+	source := &DirSuiteFileIterator{RootDir: rootDir, SuiteExts: suiteExts, XSuiteExts: xsuiteExts}
 	source.init()
 
 	go func() {
@@ -174,7 +217,12 @@ func NewSuiteLoader(rootDir, suiteExt, xsuiteExt string) <-chan TestSuite {
 				continue
 			}
 
-			channel <- *suite
+			select {
+			case channel <- *suite:
+			case <-stop:
+				close(channel)
+				return
+			}
 		}
 
 		close(channel)
@@ -184,8 +232,8 @@ func NewSuiteLoader(rootDir, suiteExt, xsuiteExt string) <-chan TestSuite {
 }
 
 // ValidateSuites detects syntax errors in all test suites in the root directory.
-func ValidateSuites(rootDir, suiteExt, xsuiteExt string) error {
-	source := &DirSuiteFileIterator{RootDir: rootDir, SuiteExt: suiteExt, XSuiteExt: xsuiteExt}
+func ValidateSuites(rootDir string, suiteExts, xsuiteExts []string) error {
+	source := &DirSuiteFileIterator{RootDir: rootDir, SuiteExts: suiteExts, XSuiteExts: xsuiteExts}
 	source.init()
 
 	errs := make([]*SuiteFileError, 0)
@@ -240,12 +288,153 @@ func (e SuitesValidationError) Error() string {
 
 func validateSuite(path string) error {
 
+	if isYAMLSuite(path) {
+		content, err := readSuiteFile(path)
+		if err != nil {
+			return err
+		}
+
+		return validateSuiteDetailed(gojsonschema.NewBytesLoader(content))
+	}
+
 	path, _ = filepath.Abs(path)
 	documentLoader := gojsonschema.NewReferenceLoader("file:///" + filepath.ToSlash(path))
 
 	return validateSuiteDetailed(documentLoader)
 }
 
+// isYAMLSuite checks whether suite file is written in YAML rather than JSON.
+func isYAMLSuite(path string) bool {
+	return strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")
+}
+
+// readSuiteFile returns JSON representation of the suite file content.
+// YAML suites are converted to JSON, so the rest of the loading and validation is shared.
+func readSuiteFile(path string) ([]byte, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isYAMLSuite(path) {
+		return content, nil
+	}
+
+	return yamlToJSON(content)
+}
+
+// yamlToJSON converts YAML document to JSON.
+func yamlToJSON(content []byte) ([]byte, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(content, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := yamlNodeValue(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+// decimalInt matches integers written in plain decimal notation
+var decimalInt = regexp.MustCompile(`^[-+]?(0|[1-9][0-9]*)$`)
+
+// yamlNodeValue converts YAML node to a value serializable to JSON.
+// Unquoted scalars which JSON has no type for are kept as written in the suite:
+// timestamps (e.g. 2020-01-01) and integers not in decimal notation (e.g. 010, 0x1F) are strings.
+func yamlNodeValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case 0:
+		return nil, nil // empty document
+
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeValue(node.Content[0])
+
+	case yaml.AliasNode:
+		return yamlNodeValue(node.Alias)
+
+	case yaml.SequenceNode:
+		result := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := yamlNodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		return result, nil
+
+	case yaml.MappingNode:
+		result := make(map[string]interface{}, len(node.Content)/2)
+		explicit := make(map[string]bool)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+			value, err := yamlNodeValue(valueNode)
+			if err != nil {
+				return nil, err
+			}
+
+			if keyNode.ShortTag() == "!!merge" {
+				mergeYAML(result, explicit, value)
+				continue
+			} // e.g. <<: *defaults, explicit keys take precedence
+
+			if keyNode.Kind == yaml.AliasNode {
+				keyNode = keyNode.Alias
+			}
+
+			k := keyNode.Value // YAML allows non-string keys (e.g. numbers), JSON does not
+			result[k] = value
+			explicit[k] = true
+		}
+		return result, nil
+
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!timestamp":
+			return node.Value, nil
+
+		case "!!int":
+			if !decimalInt.MatchString(node.Value) {
+				return node.Value, nil
+			}
+		}
+
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("line %d: unsupported YAML node", node.Line)
+}
+
+// mergeYAML copies keys of merged mapping(s) which are not set explicitly
+func mergeYAML(result map[string]interface{}, explicit map[string]bool, merged interface{}) {
+	switch typed := merged.(type) {
+	case map[string]interface{}:
+		for k, v := range typed {
+			if !explicit[k] {
+				result[k] = v
+			}
+		}
+
+	case []interface{}:
+		for i := len(typed) - 1; i >= 0; i-- {
+			mergeYAML(result, explicit, typed[i])
+		} // first mapping of the sequence takes precedence
+	}
+}
+
 func validateSuiteDetailed(documentLoader gojsonschema.JSONLoader) error {
 	schemaLoader := gojsonschema.NewStringLoader(suiteDetailedSchema)
 
@@ -282,6 +471,10 @@ func validateDuplicateTestNamesInSuite(suiteContent interface{}) error {
 
 	var arr []interface{}
 
+	if m, ok := suiteContent.(map[string]interface{}); ok {
+		suiteContent = m["tests"]
+	} // suite with setup/teardown calls
+
 	arr, ok := suiteContent.([]interface{})
 	if !ok {
 		return errors.New("test suite is not an array")
@@ -323,16 +516,181 @@ func validateDuplicateTestNamesInSuite(suiteContent interface{}) error {
 const suiteDetailedSchema = `
 {
   "$schema": "http://json-schema.org/draft-04/schema#",
-  "type": "array",
+  "type": ["array", "object"],
   "items": {
-    "type": "object",
-    "properties": {
-      "name": {
-        "type": "string"
+    "$ref": "#/definitions/testCase"
+  },
+  "properties": {
+    "beforeAll": {
+      "$ref": "#/definitions/calls"
+    },
+    "afterAll": {
+      "$ref": "#/definitions/calls"
+    },
+    "beforeEach": {
+      "$ref": "#/definitions/calls"
+    },
+    "afterEach": {
+      "$ref": "#/definitions/calls"
+    },
+    "maxDuration": {
+      "$ref": "#/definitions/duration"
+    },
+    "tags": {
+      "$ref": "#/definitions/tags"
+    },
+    "tests": {
+      "type": "array",
+      "items": {
+        "$ref": "#/definitions/testCase"
+      }
+    }
+  },
+  "required": ["tests"],
+  "additionalProperties": false,
+  "definitions": {
+    "duration": {
+      "type": "string",
+      "pattern": "^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$"
+    },
+    "tlsVersion": {
+      "type": "string",
+      "enum": ["1.0", "1.1", "1.2", "1.3"]
+    },
+    "tags": {
+      "type": "array",
+      "items": {
+        "type": "string",
+        "pattern": "^[^,\\s]+$"
+      }
+    },
+    "auth": {
+      "type": "object",
+      "properties": {
+        "basic": {
+          "type": "object",
+          "properties": {
+            "username": {
+              "type": "string"
+            },
+            "password": {
+              "type": "string"
+            }
+          },
+          "required": ["username", "password"],
+          "additionalProperties": false
+        },
+        "bearer": {
+          "type": "string",
+          "minLength": 1
+        },
+        "oauth2": {
+          "type": "object",
+          "properties": {
+            "tokenUrl": {
+              "type": "string",
+              "minLength": 1
+            },
+            "grantType": {
+              "type": "string",
+              "enum": ["client_credentials", "password"]
+            },
+            "clientId": {
+              "type": "string"
+            },
+            "clientSecret": {
+              "type": "string"
+            },
+            "username": {
+              "type": "string"
+            },
+            "password": {
+              "type": "string"
+            },
+            "scope": {
+              "type": "string"
+            },
+            "credentialsInBody": {
+              "type": "boolean"
+            }
+          },
+          "required": ["tokenUrl", "clientId"],
+          "additionalProperties": false
+        }
       },
-      "description": {
-        "type": "string"
+      "minProperties": 1,
+      "maxProperties": 1,
+      "additionalProperties": false
+    },
+    "sign": {
+      "type": "object",
+      "properties": {
+        "hmac": {
+          "type": "object",
+          "properties": {
+            "algorithm": {
+              "type": "string",
+              "enum": ["sha1", "sha256", "sha512"]
+            },
+            "secret": {
+              "type": "string"
+            },
+            "canonical": {
+              "type": "string",
+              "minLength": 1
+            },
+            "header": {
+              "type": "string"
+            },
+            "value": {
+              "type": "string"
+            },
+            "encoding": {
+              "type": "string",
+              "enum": ["base64", "hex"]
+            }
+          },
+          "required": ["secret", "canonical"],
+          "additionalProperties": false
+        },
+        "awsSigV4": {
+          "type": "object",
+          "properties": {
+            "accessKey": {
+              "type": "string"
+            },
+            "secretKey": {
+              "type": "string"
+            },
+            "sessionToken": {
+              "type": "string"
+            },
+            "region": {
+              "type": "string",
+              "minLength": 1
+            },
+            "service": {
+              "type": "string",
+              "minLength": 1
+            }
+          },
+          "required": ["accessKey", "secretKey", "region", "service"],
+          "additionalProperties": false
+        }
       },
+      "minProperties": 1,
+      "maxProperties": 1,
+      "additionalProperties": false
+    },
+    "testCase": {
+      "type": "object",
+      "properties": {
+        "name": {
+          "type": "string"
+        },
+        "description": {
+          "type": "string"
+        },
 	  "args": {
 		"type": "object",
 		"minProperties": 1,
@@ -340,97 +698,175 @@ const suiteDetailedSchema = `
 		  "type": ["string", "number", "boolean", "null"]
 	    }
 	  },
-      "ignore": {
-        "type": "string",
-        "minLength": 10
+        "ignore": {
+          "type": "string",
+          "minLength": 10
+        },
+        "maxDuration": {
+          "$ref": "#/definitions/duration"
+        },
+        "tags": {
+          "$ref": "#/definitions/tags"
+        },
+        "cookieJar": {
+          "type": "boolean"
+        },
+        "auth": {
+          "$ref": "#/definitions/auth"
+        },
+        "sign": {
+          "$ref": "#/definitions/sign"
+        },
+        "timeout": {
+          "$ref": "#/definitions/duration"
+        },
+        "calls": {
+          "$ref": "#/definitions/calls"
+        }
       },
-      "calls": {
-        "type": "array",
-        "items": {
-          "type": "object",
-          "properties": {
+      "additionalProperties": false,
+      "required": [
+	  "name", 
+        "calls"
+      ]
+    },
+    "calls": {
+      "type": "array",
+      "items": {
+        "$ref": "#/definitions/call"
+      }
+    },
+    "call": {
+      "type": "object",
+      "properties": {
 		    "description": {
 			  "type": "string"
 		    },
-            "args": {
-              "type": "object",
-              "minProperties": 1,
-              "additionalProperties": {
-                "type": ["string", "number", "boolean", "null"]
+        "args": {
+          "type": "object",
+          "minProperties": 1,
+          "additionalProperties": {
+            "type": ["string", "number", "boolean", "null"]
 			  }
+        },
+        "on": {
+          "type": "object",
+          "minProperties": 1,
+          "properties": {
+            "method": {
+              "type": "string",
+              "enum": [
+                "GET",
+                "POST",
+                "PUT",
+                "DELETE",
+                "HEAD",
+                "OPTIONS",
+                "PATCH",
+                "CONNECT",
+                "TRACE"
+              ]
             },
-            "on": {
+            "url": {
+              "type": "string"
+            },
+            "headers": {
               "type": "object",
               "minProperties": 1,
-              "properties": {
-                "method": {
-                  "type": "string",
-                  "enum": [
-                    "GET",
-                    "POST",
-                    "PUT",
-                    "DELETE",
-                    "HEAD",
-                    "OPTIONS",
-                    "PATCH",
-                    "CONNECT",
-                    "TRACE"
-                  ]
-                },
-                "url": {
-                  "type": "string"
-                },
-                "headers": {
-                  "type": "object",
-                  "minProperties": 1,
 				  "additionalProperties": {
 					"type": "string"
 				  }
-                },
-                "params": {
-                  "type": "object",
-                  "minProperties": 1,
+            },
+            "params": {
+              "type": "object",
+              "minProperties": 1,
 				  "additionalProperties": {
 					"type": "string"
 				  }
+            },
+            "body": {
+              "oneOf": [
+                {
+                  "type": "string"
                 },
-                "body": {
-                  "oneOf": [
-                    {
-                      "type": "string"
-                    },
-                    {
-                      "type": "object"
-                    }
-                  ]
+                {
+                  "type": "object"
                 },
-                "bodyFile": {
-                  "type": "string"
+                {
+                  "type": "array"
+                }
+              ]
+            },
+            "bodyFile": {
+              "type": "string"
+            },
+            "form": {
+              "type": "object",
+              "additionalProperties": {
+                "type": "string"
+              }
+            },
+            "multipart": {
+              "type": "object",
+              "properties": {
+                "fields": {
+                  "type": "object",
+                  "additionalProperties": {
+                    "type": "string"
+                  }
+                },
+                "files": {
+                  "type": "array",
+                  "items": {
+                    "type": "object",
+                    "properties": {
+                      "name": {
+                        "type": "string"
+                      },
+                      "file": {
+                        "type": "string"
+                      },
+                      "filename": {
+                        "type": "string"
+                      },
+                      "contentType": {
+                        "type": "string"
+                      }
+                    },
+                    "required": [
+                      "name",
+                      "file"
+                    ],
+                    "additionalProperties": false
+                  }
                 }
               },
-              "required": [
-                "method",
-                "url"
-              ],
               "additionalProperties": false
+            }
+          },
+          "required": [
+            "method",
+            "url"
+          ],
+          "additionalProperties": false
+        },
+        "expect": {
+          "type": "object",
+          "minProperties": 1,
+          "properties": {
+            "statusCode": {
+              "type": "integer"
+            },
+            "contentType": {
+              "type": "string"
             },
-            "expect": {
+            "headers": {
               "type": "object",
               "minProperties": 1,
-              "properties": {
-                "statusCode": {
-                  "type": "integer"
-                },
-                "contentType": {
-                  "type": "string"
-                },
-                "headers": {
-                  "type": "object",
-                  "minProperties": 1,
 				  "additionalProperties": {
 					"type": "string"
 				  }
-                },
+            },
 				"body": {
 					"type": "object",
 					"minProperties": 1
@@ -446,51 +882,188 @@ const suiteDetailedSchema = `
 				"bodySchema": {
 					"type": "object"
 				},
-                "bodySchemaFile": {
-                  "type": "string"
-                },
-                "bodySchemaURI": {
-                  "type": "string"
-                },
-                "absent": {
-                  "type": "array",
-                  "minItems": 1,
+            "bodySchemaFile": {
+              "type": "string"
+            },
+            "bodySchemaURI": {
+              "type": "string"
+            },
+            "absent": {
+              "type": "array",
+              "minItems": 1,
 				  "items": {
 				    "type": "string"
 				  }
+            },
+            "maxDuration": {
+              "$ref": "#/definitions/duration"
+            },
+            "openapi": {
+              "type": "object",
+              "properties": {
+                "file": {
+                  "type": "string",
+                  "minLength": 1
+                },
+                "operationId": {
+                  "type": "string"
                 }
               },
+              "required": ["file"],
               "additionalProperties": false
             },
-            "remember": {
+            "bodySize": {
               "type": "object",
               "minProperties": 1,
               "properties": {
-                "bodyPath": {
-                  "type": "object",
-                  "minProperties": 1
+                "min": {
+                  "type": "integer",
+                  "minimum": 0
                 },
-                "headers": {
-                  "type": "object",
-                  "minProperties": 1,
+                "max": {
+                  "type": "integer",
+                  "minimum": 0
+                }
+              },
+              "additionalProperties": false
+            },
+            "bodySha256": {
+              "type": "string"
+            },
+            "bodyEqualsFile": {
+              "type": "string"
+            },
+            "bodyType": {
+              "type": "string"
+            },
+            "cookies": {
+              "type": "object",
+              "minProperties": 1,
+              "additionalProperties": {
+                "type": "object",
+                "properties": {
+                  "value": {
+                    "type": "string"
+                  },
+                  "path": {
+                    "type": "string"
+                  },
+                  "domain": {
+                    "type": "string"
+                  },
+                  "secure": {
+                    "type": "boolean"
+                  },
+                  "httpOnly": {
+                    "type": "boolean"
+                  },
+                  "sameSite": {
+                    "type": "string",
+                    "enum": ["Lax", "Strict", "None"]
+                  },
+                  "maxAge": {
+                    "type": "integer"
+                  }
+                },
+                "additionalProperties": false
+              }
+            },
+            "tls": {
+              "type": "object",
+              "minProperties": 1,
+              "properties": {
+                "version": {
+                  "$ref": "#/definitions/tlsVersion"
+                },
+                "minVersion": {
+                  "$ref": "#/definitions/tlsVersion"
+                },
+                "certValidFor": {
+                  "$ref": "#/definitions/duration"
+                }
+              },
+              "additionalProperties": false
+            }
+          },
+          "additionalProperties": false
+        },
+        "remember": {
+          "type": "object",
+          "minProperties": 1,
+          "properties": {
+            "bodyPath": {
+              "type": "object",
+              "minProperties": 1
+            },
+            "headers": {
+              "type": "object",
+              "minProperties": 1,
 				  "additionalProperties": {
 					"type": "string"
 				  }
+            },
+            "cookies": {
+              "type": "object",
+              "minProperties": 1,
+              "additionalProperties": {
+                "type": "string"
+              }
+            },
+            "bodyFile": {
+              "type": "object",
+              "properties": {
+                "path": {
+                  "type": "string"
+                },
+                "var": {
+                  "type": "string",
+                  "minLength": 1
                 }
               },
+              "required": ["var"],
               "additionalProperties": false
             }
           },
-          "required": ["on", "expect"],
-		  "additionalProperties": false
+          "additionalProperties": false
+        },
+        "retry": {
+          "type": "object",
+          "properties": {
+            "attempts": {
+              "type": "integer",
+              "minimum": 1
+            },
+            "interval": {
+              "$ref": "#/definitions/duration"
+            },
+            "backoff": {
+              "type": "number",
+              "minimum": 1
+            },
+            "stopOnStatus": {
+              "type": "array",
+              "minItems": 1,
+              "items": {
+                "type": "integer"
+              }
+            }
+          },
+          "required": ["attempts"],
+          "additionalProperties": false
+        },
+        "auth": {
+          "$ref": "#/definitions/auth"
+        },
+        "sign": {
+          "$ref": "#/definitions/sign"
+        },
+        "timeout": {
+          "$ref": "#/definitions/duration"
         }
-      }
-    },
-    "additionalProperties": false,
-    "required": [
-	  "name", 
-      "calls"
-    ]
+      },
+      "required": ["on", "expect"],
+		  "additionalProperties": false
+    }
   }
 }
 `
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -355,6 +359,135 @@ func Test_validateSuiteShape(t *testing.T) {
 			]`),
 			wantErr: "duplicate test case names: [testOne]",
 		},
+		{
+			name: "suite with setup and teardown calls",
+			args: gojsonschema.NewStringLoader(`{
+				"beforeAll": [{"on": {"method": "POST", "url":"login"}, "expect": {"statusCode":200}}],
+				"afterEach": [{"on": {"method": "DELETE", "url":"data"}, "expect": {"statusCode":204}}],
+				"tests": [
+					{"name": "testOne", "calls": [{"on": {"method": "GET", "url":"smth"}, "expect": {"statusCode":200}}]}
+				]
+			}`),
+			wantErr: "",
+		},
+		{
+			name: "suite object requires tests",
+			args: gojsonschema.NewStringLoader(`{
+				"beforeAll": [{"on": {"method": "POST", "url":"login"}, "expect": {"statusCode":200}}]
+			}`),
+			wantErr: "tests is required",
+		},
+		{
+			name: "test case names can't duplicate in suite object",
+			args: gojsonschema.NewStringLoader(`{"tests": [
+				{"name": "testOne", "calls": [{"on": {"method": "GET",   "url":"smth"}, "expect": {"statusCode":200}}]},
+				{"name": "testOne", "calls": [{"on": {"method": "POST",  "url":"smth"}, "expect": {"statusCode":201}}]}
+			]}`),
+			wantErr: "duplicate test case names: [testOne]",
+		},
+		{
+			name: "suite and test case tags allowed",
+			args: gojsonschema.NewStringLoader(`{"tags": ["payments"], "tests": [
+				{"name": "testOne", "tags": ["smoke"], "calls": [{"on": {"method": "GET", "url":"smth"}, "expect": {"statusCode":200}}]}
+			]}`),
+			wantErr: "",
+		},
+		{
+			name: "tag can't contain comma",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "tags": ["smoke,slow"], "calls": [{"on": {"method": "GET", "url":"smth"}, "expect": {"statusCode":200}}]}
+			]`),
+			wantErr: "tags.0",
+		},
+		{
+			name: "openapi expectation requires file",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "calls": [{"on": {"method": "GET", "url":"smth"}, "expect": {"openapi": {"operationId": "getUser"}}}]}
+			]`),
+			wantErr: "file is required",
+		},
+		{
+			name: "form and multipart request bodies allowed",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "calls": [{"on": {"method": "POST", "url":"smth", "form": {"name": "{user}"}}, "expect": {"statusCode":200}}]},
+				{"name": "testTwo", "calls": [{"on": {"method": "POST", "url":"smth", "multipart": {"fields": {"name": "John"}, "files": [{"name": "avatar", "file": "avatar.png", "contentType": "image/png"}]}}, "expect": {"statusCode":200}}]}
+			]`),
+			wantErr: "",
+		},
+		{
+			name: "multipart file requires path",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "calls": [{"on": {"method": "POST", "url":"smth", "multipart": {"files": [{"name": "avatar"}]}}, "expect": {"statusCode":200}}]}
+			]`),
+			wantErr: "file is required",
+		},
+		{
+			name: "cookie jar opt-out and cookie expectations allowed",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "cookieJar": false, "calls": [{"on": {"method": "POST", "url":"smth"}, "expect": {"cookies": {"session": {"httpOnly": true, "sameSite": "Lax"}}}, "remember": {"cookies": {"sid": "session"}}}]}
+			]`),
+			wantErr: "",
+		},
+		{
+			name: "cookie same site is validated",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "calls": [{"on": {"method": "POST", "url":"smth"}, "expect": {"cookies": {"session": {"sameSite": "Loose"}}}}]}
+			]`),
+			wantErr: "sameSite",
+		},
+		{
+			name: "test case and call auth allowed",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "auth": {"basic": {"username": "john", "password": "{password}"}}, "calls": [
+					{"on": {"method": "GET", "url":"smth"}, "expect": {"statusCode":200}, "auth": {"oauth2": {"tokenUrl": "/token", "clientId": "bozr", "grantType": "password"}}}
+				]}
+			]`),
+			wantErr: "",
+		},
+		{
+			name: "only one auth scheme allowed",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "auth": {"bearer": "abc", "basic": {"username": "john", "password": "secret"}}, "calls": [{"on": {"method": "GET", "url":"smth"}, "expect": {"statusCode":200}}]}
+			]`),
+			wantErr: "auth",
+		},
+		{
+			name: "request signing allowed",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "sign": {"awsSigV4": {"accessKey": "a", "secretKey": "s", "region": "us-east-1", "service": "s3"}}, "calls": [
+					{"on": {"method": "GET", "url":"smth"}, "expect": {"statusCode":200}, "sign": {"hmac": {"secret": "s", "canonical": "{req:method}", "encoding": "hex"}}}
+				]}
+			]`),
+			wantErr: "",
+		},
+		{
+			name: "hmac signing requires canonical string",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "calls": [{"on": {"method": "GET", "url":"smth"}, "expect": {"statusCode":200}, "sign": {"hmac": {"secret": "s"}}}]}
+			]`),
+			wantErr: "canonical is required",
+		},
+		{
+			name: "tls expectation requires known version",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "calls": [{"on": {"method": "GET", "url":"smth"}, "expect": {"tls": {"minVersion": "1.4", "certValidFor": "720h"}}}]}
+			]`),
+			wantErr: "minVersion must be one of",
+		},
+		{
+			name: "timeout is a duration",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "timeout": "5s", "calls": [{"on": {"method": "GET", "url":"smth"}, "expect": {"statusCode":200}, "timeout": "5 minutes"}]}
+			]`),
+			wantErr: "0.calls.0.timeout, Error: Does not match pattern",
+		},
+		{
+			name: "test case timeout is a duration",
+			args: gojsonschema.NewStringLoader(`[
+				{"name": "testOne", "timeout": "-5s", "calls": [{"on": {"method": "GET", "url":"smth"}, "expect": {"statusCode":200}}]}
+			]`),
+			wantErr: "0.timeout, Error: Does not match pattern",
+		},
 		{
 			name: "test case name is required",
 			args: gojsonschema.NewStringLoader(`[
@@ -381,3 +514,221 @@ func Test_validateSuiteShape(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateYAMLSuite(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "valid suite with comments and block body",
+			content: `
+# comment explaining edge case
+- name: one
+  calls:
+    - on:
+        method: POST
+        url: /users
+        body: |
+          {"name": "John"}
+      expect:
+        statusCode: 201
+        exactBody:
+          name: John
+`,
+			wantErr: "",
+		},
+		{
+			name: "invalid field is reported",
+			content: `
+- name: one
+  calls:
+    - on: {method: GET, url: smth}
+      expect: {statusCode: "200"}
+`,
+			wantErr: "Field: 0.calls.0.expect.statusCode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := yamlToJSON([]byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = validateSuiteDetailed(gojsonschema.NewBytesLoader(content))
+
+			if err == nil && tt.wantErr == "" {
+				return
+			}
+
+			if err != nil && tt.wantErr != "" && strings.Contains(err.Error(), tt.wantErr) {
+				return
+			}
+
+			t.Errorf("validateSuiteDetailed() error = %v, wantErr %v", err, tt.wantErr)
+		})
+	}
+}
+
+func TestYAMLToJSON_NonStringKeys(t *testing.T) {
+	content, err := yamlToJSON([]byte("1: one\ntrue: yes\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m map[string]interface{}
+	json.Unmarshal(content, &m)
+
+	if m["1"] != "one" || m["true"] != "yes" {
+		t.Errorf("unexpected conversion: %s", content)
+	}
+}
+
+func TestYAMLToJSON_ScalarsAsWritten(t *testing.T) {
+	content, err := yamlToJSON([]byte(`
+date: 2020-01-01
+time: 2020-01-01T10:00:00Z
+octal: 010
+hex: 0x1F
+int: -10
+float: 1.5
+bool: true
+null: ~
+quoted: "010"
+defaults: &defaults {method: GET, url: /users}
+on:
+  <<: *defaults
+  url: /orders
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m map[string]interface{}
+	json.Unmarshal(content, &m)
+
+	expected := map[string]interface{}{
+		"date":     "2020-01-01",
+		"time":     "2020-01-01T10:00:00Z",
+		"octal":    "010",
+		"hex":      "0x1F",
+		"int":      float64(-10),
+		"float":    1.5,
+		"bool":     true,
+		"null":     nil,
+		"quoted":   "010",
+		"defaults": map[string]interface{}{"method": "GET", "url": "/users"},
+		"on":       map[string]interface{}{"method": "GET", "url": "/orders"},
+	}
+
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("unexpected conversion: %s", content)
+	}
+}
+
+func TestDirSuiteFileIterator_YAMLSuites(t *testing.T) {
+	initLogger()
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.suite.json", "b.suite.yaml", "c.suite.yml", "d.xsuite.yaml", "e.yaml"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("[]"), 0644)
+	}
+
+	it := &DirSuiteFileIterator{RootDir: dir, SuiteExts: suiteExts, XSuiteExts: ignoredSuiteExts}
+	it.init()
+
+	got := make(map[string]SuiteFile)
+	for it.HasNext() {
+		sf := it.Next()
+		got[filepath.Base(sf.Path)] = *sf
+	}
+
+	if len(got) != 4 {
+		t.Fatalf("expected 4 suite files, got %v", got)
+	}
+
+	if sf := got["c.suite.yml"]; sf.Ext != ".suite.yml" || sf.Ignored {
+		t.Errorf("unexpected suite file: %+v", sf)
+	}
+
+	if sf := got["d.xsuite.yaml"]; sf.Ext != ".xsuite.yaml" || !sf.Ignored {
+		t.Errorf("unexpected ignored suite file: %+v", sf)
+	}
+}
+
+func TestSuiteDefinitionUnmarshal(t *testing.T) {
+	var arr suiteDefinition
+	err := json.Unmarshal([]byte(` [{"name": "one"}]`), &arr)
+	if err != nil || len(arr.Tests) != 1 {
+		t.Errorf("array suite is not parsed: %+v, %v", arr, err)
+	}
+
+	var obj suiteDefinition
+	err = json.Unmarshal([]byte(`{"beforeEach": [{"on": {"url": "login"}}], "tests": [{"name": "one"}]}`), &obj)
+	if err != nil || len(obj.Tests) != 1 || len(obj.BeforeEach) != 1 || obj.BeforeEach[0].On.URL != "login" {
+		t.Errorf("object suite is not parsed: %+v, %v", obj, err)
+	}
+}
+
+func TestEditorSchema_AcceptsValidSuites(t *testing.T) {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewReferenceLoader("file://" + filepath.ToSlash(mustAbs(t, "assets/test.schema.json"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suites := []string{
+		`{
+			"beforeAll": [{"on": {"method": "POST", "url": "login"}, "expect": {"statusCode": 200}, "remember": {"cookies": {"session": "session"}, "bodyFile": {"var": "file"}}}],
+			"beforeEach": [], "afterEach": [], "afterAll": [],
+			"maxDuration": "1s", "tags": ["smoke"],
+			"tests": [{
+				"name": "all fields", "tags": ["slow"], "cookieJar": false, "timeout": "5s",
+				"auth": {"bearer": "{token}"}, "sign": {"hmac": {"secret": "s", "canonical": "{method}"}},
+				"calls": [{
+					"on": {"method": "POST", "url": "upload", "form": {"a": "b"}, "multipart": {"files": [{"name": "f", "file": "a.txt"}]}},
+					"expect": {
+						"statusCode": 200, "maxDuration": "1s", "openapi": {"file": "api.yaml"}, "bodySize": {"max": 10},
+						"bodySha256": "abc", "bodyEqualsFile": "a.bin", "bodyType": "image/png",
+						"cookies": {"session": {"httpOnly": true}}, "tls": {"minVersion": "1.2"}
+					},
+					"retry": {"attempts": 3}, "auth": {"basic": {"username": "u", "password": "p"}}, "timeout": "1s"
+				}]
+			}]
+		}`,
+	}
+
+	files, _ := filepath.Glob("examples/*.suite.json")
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		suites = append(suites, string(content))
+	}
+
+	for i, suite := range suites {
+		if err := validateSuiteDetailed(gojsonschema.NewStringLoader(suite)); err != nil {
+			t.Fatalf("suite %d is invalid: %v", i, err)
+		}
+
+		result, err := schema.Validate(gojsonschema.NewStringLoader(suite))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !result.Valid() {
+			t.Errorf("suite %d is rejected by editor schema: %v", i, result.Errors())
+		}
+	}
+}
+
+func mustAbs(t *testing.T, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return abs
+}
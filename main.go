@@ -13,6 +13,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"moul.io/http2curl"
@@ -25,26 +26,52 @@ const (
 func init() {
 	flag.Usage = func() {
 		h := "Usage:\n"
-		h += "  bozr [OPTIONS] (DIR|FILE)\n\n"
+		h += "  bozr [OPTIONS] (DIR|FILE)\n"
+		h += "  bozr serve [OPTIONS] (DIR|FILE)   Start mock server answering with responses described in suites\n"
+		h += "  bozr record --target URL [OPTIONS] Record calls proxied to the target into a suite file\n"
+		h += "  bozr generate --openapi FILE [OPTIONS] Generate skeleton suites from OpenAPI 3 spec\n\n"
 
 		h += "Options:\n"
 		h += "  -d, --debug                     Enable debug mode\n"
 		h += "  -H, --host                      Base URI prefix for test calls\n"
 		h += "      --header                    Extra header to add to each request\n"
+		h += "      --env                       Name of environment profile (base url, headers, TLS, variables) to use\n"
+		h += "      --env-file                  Environment profiles file. Default is bozr.env.json in the suites root\n"
+		h += "      --insecure                  Skip verification of server TLS certificate\n"
+		h += "      --ca-file                   PEM file with CA certificates to verify server certificate against\n"
+		h += "      --cert-file                 PEM file with client certificate (mutual TLS)\n"
+		h += "      --key-file                  PEM file with private key of client certificate\n"
+		h += "      --server-name               Server name to verify certificate against and to send in SNI\n"
+		h += "      --tls-min-version           Lowest accepted TLS version: 1.0, 1.1, 1.2 or 1.3\n"
 		h += "  -w, --worker                    Execute in parallel with specified number of workers\n"
 		h += "      --rewrite-response-location Rewrite response header (Location) before it get checked against expectations\n"
 		h += "      --throttle                  Execute no more than specified number of requests per second (in suite)\n"
+		h += "      --fail-fast                 Stop execution after the first failed test case\n"
+		h += "      --timeout                   Time limit of each request, e.g. 30s. Not limited by default\n"
+		h += "      --run-timeout               Time limit of the whole run, e.g. 30m. Not limited by default\n"
+		h += "      --tags                      Run only test cases with any of comma separated tags\n"
+		h += "      --exclude-tags              Skip test cases with any of comma separated tags\n"
+		h += "      --run                       Run only test cases which '<suite>/<case name>' matches regular expression\n"
 		h += "  -h, --help                      Print usage\n"
 		h += "  -i, --info                      Enable info mode. Print request and response details\n"
 		h += "      --info-curl                 Enable info mode. Print request and response details. Request is printed as curl command\n"
 		h += "      --junit                     Enable junit xml reporter\n"
 		h += "      --junit-output              Destination for junit report files\n"
+		h += "      --json-report               Write results with request/response details to the specified json file\n"
+		h += "      --html-report               Write results to the specified self-contained html file\n"
+		h += "      --coverage-openapi          Report API coverage against the specified OpenAPI 3 spec\n"
+		h += "      --coverage-output           Write API coverage report to the specified file, html or json by extension\n"
 		h += "  -v, --version                   Print version information and quit\n\n"
 
+		h += "Exit codes:\n"
+		h += "  0 all tests passed, 1 tests failed, 2 test suites are invalid, 3 runtime error, 4 run timeout exceeded\n\n"
+
 		h += "Examples:\n"
 		h += "  bozr ./examples\n"
 		h += "  bozr -w 2 ./examples\n"
 		h += "  bozr -H http://example.com ./examples \n"
+		h += "  bozr --env staging ./examples \n"
+		h += "  bozr --tags smoke --exclude-tags slow ./examples \n"
 
 		fmt.Fprint(os.Stderr, h)
 	}
@@ -65,6 +92,9 @@ var (
 	suitesDir                 string
 	hostFlag                  string
 	headersFlag               stringArray
+	envFlag                   string
+	envFileFlag               string
+	tlsFlags                  TLSOptions
 	workersFlag               int
 	throttleFlag              int
 	infoFlag                  bool
@@ -74,14 +104,32 @@ var (
 	versionFlag               bool
 	junitFlag                 bool
 	junitOutputFlag           string
+	jsonReportFlag            string
+	htmlReportFlag            string
+	coverageOpenAPIFlag       string
+	coverageOutputFlag        string
+	failFastFlag              bool
+	timeoutFlag               time.Duration
+	runTimeoutFlag            time.Duration
+	tagsFlag                  string
+	excludeTagsFlag           string
+	runFlag                   string
 	rewriteResponseHeaderFlag string
 
 	debug *log.Logger
 )
 
+// Process exit codes
 const (
-	suiteExt        = ".suite.json"
-	ignoredSuiteExt = ".xsuite.json"
+	exitCodeTestsFailed   = 1
+	exitCodeInvalidSuites = 2
+	exitCodeRuntimeError  = 3
+	exitCodeRunTimeout    = 4
+)
+
+var (
+	suiteExts        = []string{".suite.json", ".suite.yaml", ".suite.yml"}
+	ignoredSuiteExts = []string{".xsuite.json", ".xsuite.yaml", ".xsuite.yml"}
 )
 
 func initLogger() {
@@ -95,6 +143,22 @@ func initLogger() {
 }
 
 func main() {
+	defer exitOnPanic()
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			serveCommand(os.Args[2:])
+			return
+		case "record":
+			recordCommand(os.Args[2:])
+			return
+		case "generate":
+			generateCommand(os.Args[2:])
+			return
+		}
+	}
+
 	flag.BoolVar(&debugFlag, "d", false, "Enable debug mode.")
 	flag.BoolVar(&debugFlag, "debug", false, "Enable debug mode")
 
@@ -104,9 +168,23 @@ func main() {
 
 	flag.StringVar(&hostFlag, "H", "", "Test server address. Example: http://example.com/api.")
 	flag.Var(&headersFlag, "header", "Extra header to add to each request")
+	flag.StringVar(&envFlag, "env", "", "Name of environment profile to use")
+	flag.StringVar(&envFileFlag, "env-file", "", "Environment profiles file. Default is "+envFileName+" in the suites root")
+	flag.BoolVar(&tlsFlags.Insecure, "insecure", false, "Skip verification of server TLS certificate")
+	flag.StringVar(&tlsFlags.CAFile, "ca-file", "", "PEM file with CA certificates to verify server certificate against")
+	flag.StringVar(&tlsFlags.CertFile, "cert-file", "", "PEM file with client certificate (mutual TLS)")
+	flag.StringVar(&tlsFlags.KeyFile, "key-file", "", "PEM file with private key of client certificate")
+	flag.StringVar(&tlsFlags.ServerName, "server-name", "", "Server name to verify certificate against and to send in SNI")
+	flag.StringVar(&tlsFlags.MinVersion, "tls-min-version", "", "Lowest accepted TLS version: 1.0, 1.1, 1.2 or 1.3")
 	flag.IntVar(&workersFlag, "w", 1, "Execute test sutes in parallel with provided numer of workers. Default is 1.")
 	flag.StringVar(&rewriteResponseHeaderFlag, "rewrite-response-location", "", "Rewrite response header (Location) before it get checked against expectations")
 	flag.IntVar(&throttleFlag, "throttle", 0, "Execute no more than specified number of requests per second (in suite)")
+	flag.BoolVar(&failFastFlag, "fail-fast", false, "Stop execution after the first failed test case")
+	flag.DurationVar(&timeoutFlag, "timeout", 0, "Time limit of each request, e.g. 30s. Not limited by default")
+	flag.DurationVar(&runTimeoutFlag, "run-timeout", 0, "Time limit of the whole run, e.g. 30m. Not limited by default")
+	flag.StringVar(&tagsFlag, "tags", "", "Run only test cases with any of comma separated tags")
+	flag.StringVar(&excludeTagsFlag, "exclude-tags", "", "Skip test cases with any of comma separated tags")
+	flag.StringVar(&runFlag, "run", "", "Run only test cases which '<suite>/<case name>' matches regular expression")
 
 	flag.BoolVar(&helpFlag, "h", false, "Print usage")
 	flag.BoolVar(&helpFlag, "help", false, "Print usage")
@@ -116,6 +194,10 @@ func main() {
 
 	flag.BoolVar(&junitFlag, "junit", false, "Enable junit xml reporter")
 	flag.StringVar(&junitOutputFlag, "junit-output", "./report", "Destination for junit report files. Default ")
+	flag.StringVar(&jsonReportFlag, "json-report", "", "Write results with request/response details to the specified json file")
+	flag.StringVar(&htmlReportFlag, "html-report", "", "Write results to the specified self-contained html file")
+	flag.StringVar(&coverageOpenAPIFlag, "coverage-openapi", "", "Report API coverage against the specified OpenAPI 3 spec")
+	flag.StringVar(&coverageOutputFlag, "coverage-output", "", "Write API coverage report to the specified file, html or json by extension")
 
 	flag.Parse()
 
@@ -131,14 +213,6 @@ func main() {
 		return
 	}
 
-	if len(hostFlag) > 0 {
-		_, err := url.ParseRequestURI(hostFlag)
-		if err != nil {
-			terminate("Invalid host is specified.")
-			return
-		}
-	}
-
 	if workersFlag < 1 || workersFlag > 9 {
 		fmt.Println("Invalid number of workers:  [", workersFlag, "]. Setting to default [1]")
 		workersFlag = 1
@@ -149,43 +223,104 @@ func main() {
 	if suitesDir == "" {
 		flag.Usage()
 		fmt.Println()
-		terminate("You must specify a directory or file with tests.")
+		terminate(exitCodeRuntimeError, "You must specify a directory or file with tests.")
 		return
 	}
 
 	// check specified source dir/file exists
 	_, err := os.Lstat(suitesDir)
 	if err != nil {
-		terminate(err.Error())
+		terminate(exitCodeRuntimeError, err.Error())
 		return
 	}
 
-	err = ValidateSuites(suitesDir, suiteExt, ignoredSuiteExt)
+	var envProfile *EnvProfile
+	if envFlag != "" {
+		if envFileFlag == "" {
+			envFileFlag = defaultEnvFile(suitesDir)
+		}
+
+		envProfile, err = loadEnvProfile(envFileFlag, envFlag)
+		if err != nil {
+			terminate(exitCodeRuntimeError, err.Error())
+			return
+		}
+
+		if hostFlag == "" {
+			hostFlag = envProfile.BaseURL
+		} // command line takes precedence
+	}
+
+	if len(hostFlag) > 0 {
+		_, err := url.ParseRequestURI(hostFlag)
+		if err != nil {
+			terminate(exitCodeRuntimeError, "Invalid host is specified.")
+			return
+		}
+	}
+
+	testFilter, err := NewTestFilter(tagsFlag, excludeTagsFlag, runFlag)
 	if err != nil {
-		terminate("One or more test suites are invalid.", err.Error())
+		terminate(exitCodeRuntimeError, err.Error())
 		return
 	}
-	requestConfig, err := newRequestConfig(headersFlag)
+
+	err = ValidateSuites(suitesDir, suiteExts, ignoredSuiteExts)
 	if err != nil {
-		terminate(err.Error())
+		terminate(exitCodeInvalidSuites, "One or more test suites are invalid.", err.Error())
 		return
 	}
+	requestConfig, err := newRequestConfig(headersFlag, &tlsFlags, envProfile)
+	if err != nil {
+		terminate(exitCodeRuntimeError, err.Error())
+		return
+	}
+	requestConfig.Timeout = timeoutFlag
 
 	rewriteConfig := newRewriteConfig([]ResponseRewriter{
 		&LocationRewrite{BaseURL: hostFlag, Template: rewriteResponseHeaderFlag},
 	})
 
-	loader := NewSuiteLoader(suitesDir, suiteExt, ignoredSuiteExt)
-	reporter := createReporter()
+	stop := make(chan struct{})
+	loader := NewSuiteLoader(suitesDir, suiteExts, ignoredSuiteExts, stop)
+	var coverageSpec *OpenAPISpec
+	if coverageOpenAPIFlag != "" {
+		coverageSpec, err = loadOpenAPISpec(coverageOpenAPIFlag)
+		if err != nil {
+			terminate(exitCodeRuntimeError, err.Error())
+			return
+		}
+	}
 
-	RunParallel(&RunConfig{
+	reporter := createReporter(coverageSpec)
+
+	passed, err := RunParallel(&RunConfig{
 		loader:        loader,
 		requestConfig: requestConfig,
 		rewriteConfig: rewriteConfig,
 		reporter:      reporter,
 		runSuite:      runSuite,
 		numRoutines:   workersFlag,
+		failFast:      failFastFlag,
+		filter:        testFilter,
+		throttle:      throttleFlag,
+		stop:          stop,
+		timeout:       runTimeoutFlag,
 	})
+
+	removeTempBodyFiles()
+
+	if _, ok := err.(RunTimeoutError); ok {
+		terminate(exitCodeRunTimeout, err.Error())
+	}
+
+	if err != nil {
+		terminate(exitCodeRuntimeError, err.Error())
+	}
+
+	if !passed {
+		os.Exit(exitCodeTestsFailed)
+	}
 }
 
 type RunConfig struct {
@@ -195,12 +330,57 @@ type RunConfig struct {
 	reporter      Reporter
 	runSuite      RunSuiteFunc
 	numRoutines   int
+	// stop execution after the first failed test case
+	failFast bool
+	// selects test cases to run, all are executed if nil
+	filter *TestFilter
+	// max number of requests per second in a suite, not limited if zero
+	throttle int
+	// closed to stop loading and running of the rest suites
+	stop chan struct{}
+	// time limit of the whole run, not limited if zero
+	timeout time.Duration
 }
 
-func runSuite(requestConfig *RequestConfig, rewriteConfig *RewriteConfig, suite TestSuite) []TestResult {
+func runSuite(runConfig *RunConfig, suite TestSuite) []TestResult {
 	results := []TestResult{}
 
-	throttle := NewThrottle(throttleFlag, time.Second)
+	requestConfig, rewriteConfig := runConfig.requestConfig, runConfig.rewriteConfig
+	throttle := NewThrottle(runConfig.throttle, time.Second)
+
+	suiteVars := NewVars(hostFlag)
+	suiteVars.suitePath = suite.Dir
+
+	// setup and teardown are not needed when all test cases are skipped
+	runnable := false
+	for _, testCase := range suite.Cases {
+		if testCase.Ignore == nil && runConfig.filter.SkipReason(suite, testCase) == "" {
+			runnable = true
+			break
+		}
+	}
+
+	// beforeAll and afterAll share cookies, test cases have their own ones
+	suiteConfig := requestConfig.withCookieJar()
+
+	var setupErr error
+	if err := suiteVars.AddAll(requestConfig.Vars); err != nil {
+		setupErr = fmt.Errorf("environment profile variables are invalid: %s", err)
+	} else if runnable && len(suite.BeforeAll) > 0 {
+		start := time.Now()
+		setupTraces := runCalls(suiteConfig, rewriteConfig, suite, withMaxDuration(suite.BeforeAll, suite.MaxDuration), suiteVars, throttle)
+
+		results = append(results, TestResult{
+			Suite:     suite,
+			Case:      TestCase{Name: "beforeAll"},
+			Traces:    setupTraces,
+			ExecFrame: TimeFrame{Start: start, End: time.Now()},
+		}) // setup calls are reported like a test case, e.g. for API coverage
+
+		if err := lastError(setupTraces); err != nil {
+			setupErr = fmt.Errorf("beforeAll failed: %s", err)
+		}
+	}
 
 	for _, testCase := range suite.Cases {
 
@@ -218,35 +398,63 @@ func runSuite(requestConfig *RequestConfig, rewriteConfig *RewriteConfig, suite
 			continue
 		}
 
-		vars := NewVars(hostFlag)
-		callArgsErr := vars.AddAll(testCase.Args)
-		for i, c := range testCase.Calls {
+		if reason := runConfig.filter.SkipReason(suite, testCase); reason != "" {
+			result.Skipped = true
+			result.SkippedMsg = reason
 
-			throttle.RunOrPause()
+			results = append(results, result)
+			continue
+		}
 
-			if callArgsErr != nil {
-				result.Traces = append(result.Traces, &CallTrace{ErrorCause: callArgsErr, Num: i})
+		if setupErr != nil {
+			result.Traces = append(result.Traces, &CallTrace{ErrorCause: setupErr})
+			results = append(results, result)
+			if runConfig.failFast {
 				break
 			}
+			continue
+		}
 
-			err := vars.AddAll(c.Args)
-			if err != nil {
-				result.Traces = append(result.Traces, &CallTrace{ErrorCause: err, Num: i})
-				break
-			}
+		vars := NewVars(hostFlag)
+		vars.suitePath = suite.Dir
+		vars.Inherit(suiteVars)
 
-			trace := call(requestConfig, rewriteConfig, suite.Dir, c, vars)
-			trace.Num = i
+		maxDuration := testCase.MaxDuration
+		if maxDuration == "" {
+			maxDuration = suite.MaxDuration
+		}
 
-			result.Traces = append(result.Traces, trace)
+		caseConfig := requestConfig
+		if testCase.CookieJar == nil || *testCase.CookieJar {
+			caseConfig = requestConfig.withCookieJar()
+		}
+		if testCase.Auth != nil {
+			caseConfig = caseConfig.withAuth(testCase.Auth)
+		}
+		if testCase.Sign != nil {
+			caseConfig = caseConfig.withSigning(testCase.Sign)
+		}
+		if testCase.Timeout != "" {
+			timeout, _ := time.ParseDuration(testCase.Timeout) // format is checked by the suite schema
+			caseConfig = caseConfig.withTimeout(timeout)
+		}
 
-			if trace.hasError() {
-				break
+		result.Traces = runCalls(caseConfig, rewriteConfig, suite, withMaxDuration(suite.BeforeEach, maxDuration), vars, throttle)
+
+		if lastError(result.Traces) == nil {
+			callArgsErr := vars.AddAll(testCase.Args)
+			if callArgsErr != nil && len(testCase.Calls) > 0 {
+				result.Traces = append(result.Traces, &CallTrace{ErrorCause: callArgsErr, Num: 0})
+			} else {
+				result.Traces = append(result.Traces, runCalls(caseConfig, rewriteConfig, suite, withMaxDuration(testCase.Calls, maxDuration), vars, throttle)...)
 			}
 		}
 
+		// teardown runs regardless of the test case outcome
+		result.Traces = append(result.Traces, runCalls(caseConfig, rewriteConfig, suite, withMaxDuration(suite.AfterEach, maxDuration), vars, throttle)...)
+
 		unused := vars.Unused()
-		if len(unused) != 0 {
+		if len(unused) != 0 && len(result.Traces) > 0 && !result.hasError() {
 			traces := result.Traces
 			lastTrace := traces[len(traces)-1]
 			if lastTrace.ErrorCause == nil {
@@ -257,17 +465,84 @@ func runSuite(requestConfig *RequestConfig, rewriteConfig *RewriteConfig, suite
 		result.ExecFrame.End = time.Now()
 
 		results = append(results, result)
+
+		if runConfig.failFast && result.hasError() || requestConfig.runContext().Err() != nil {
+			break
+		} // rest of the suite is not executed
+	}
+
+	if len(suite.AfterAll) > 0 && runnable {
+		start := time.Now()
+		teardownTraces := runCalls(suiteConfig, rewriteConfig, suite, withMaxDuration(suite.AfterAll, suite.MaxDuration), suiteVars, throttle)
+
+		results = append(results, TestResult{
+			Suite:     suite,
+			Case:      TestCase{Name: "afterAll"},
+			Traces:    teardownTraces,
+			ExecFrame: TimeFrame{Start: start, End: time.Now()},
+		})
 	}
 
 	return results
 }
 
-func createReporter() Reporter {
+// runCalls executes calls one by one until first failure.
+func runCalls(requestConfig *RequestConfig, rewriteConfig *RewriteConfig, suite TestSuite, calls []Call, vars *Vars, throttle *Throttle) []*CallTrace {
+	traces := []*CallTrace{}
+
+	for i, c := range calls {
+
+		throttle.RunOrPause()
+
+		err := vars.AddAll(c.Args)
+		if err != nil {
+			traces = append(traces, &CallTrace{ErrorCause: err, Num: i})
+			break
+		}
+
+		trace := call(requestConfig, rewriteConfig, suite.Dir, c, vars)
+		trace.Num = i
+
+		traces = append(traces, trace)
+
+		if trace.hasError() {
+			break
+		}
+	}
+
+	return traces
+}
+
+// lastError returns error of the last trace if any
+func lastError(traces []*CallTrace) error {
+	if len(traces) == 0 {
+		return nil
+	}
+
+	return traces[len(traces)-1].ErrorCause
+}
+
+func createReporter(coverageSpec *OpenAPISpec) Reporter {
 	reporters := []Reporter{NewConsoleReporter(infoFlag || infoCurlFlag)}
 	if junitFlag {
 		path, _ := filepath.Abs(junitOutputFlag)
 		reporters = append(reporters, NewJUnitReporter(path))
 	}
+	if jsonReportFlag != "" {
+		path, _ := filepath.Abs(jsonReportFlag)
+		reporters = append(reporters, NewJSONReporter(path))
+	}
+	if htmlReportFlag != "" {
+		path, _ := filepath.Abs(htmlReportFlag)
+		reporters = append(reporters, NewHTMLReporter(path))
+	}
+	if coverageSpec != nil {
+		path := ""
+		if coverageOutputFlag != "" {
+			path, _ = filepath.Abs(coverageOutputFlag)
+		}
+		reporters = append(reporters, NewCoverageReporter(coverageSpec, path))
+	}
 	reporter := NewMultiReporter(reporters...)
 	reporter.Init()
 
@@ -276,6 +551,71 @@ func createReporter() Reporter {
 
 func call(requestConfig *RequestConfig, rewriteConfig *RewriteConfig, suitePath string, call Call, vars *Vars) *CallTrace {
 
+	var (
+		trace    *CallTrace
+		testResp *Response
+		attempts []CallAttempt
+	)
+
+	for attempt := 1; ; attempt++ {
+		trace, testResp = callAttempt(requestConfig, rewriteConfig, suitePath, call, vars)
+
+		if call.Retry != nil {
+			a := CallAttempt{Num: attempt, ErrorCause: trace.ErrorCause, ExecFrame: trace.ExecFrame}
+			if testResp != nil {
+				a.StatusCode = testResp.http.StatusCode
+			}
+			attempts = append(attempts, a)
+			trace.Attempts = attempts
+		}
+
+		if !call.Retry.again(attempt, trace, testResp) {
+			break
+		}
+
+		delay, err := call.Retry.delay(attempt)
+		if err != nil {
+			trace.ErrorCause = err
+			return trace
+		}
+
+		debugf("Retrying call after %s (attempt %d of %d)", delay, attempt+1, call.Retry.Attempts)
+		select {
+		case <-time.After(delay):
+		case <-requestConfig.runContext().Done():
+		} // next attempt fails right away once the run is timed out
+	}
+
+	if trace.hasError() {
+		return trace
+	}
+
+	err := rememberBody(testResp, call.Remember.BPath, vars)
+	debug.Print(vars)
+	if err != nil {
+		debug.Print("Error remember")
+		trace.ErrorCause = err
+		return trace
+	}
+
+	rememberHeaders(testResp.http.Header, call.Remember.Headers, vars)
+	rememberCookies(chainCookies(testResp.http), call.Remember.Cookies, vars)
+
+	if call.Remember.BodyFile != nil {
+		err = rememberBodyFile(testResp, call.Remember.BodyFile, suitePath, vars)
+		if err != nil {
+			trace.ErrorCause = err
+			return trace
+		}
+	}
+
+	return trace
+}
+
+// callAttempt makes a single request and checks response against expectations.
+// Response is returned only if it was received.
+func callAttempt(requestConfig *RequestConfig, rewriteConfig *RewriteConfig, suitePath string, call Call, vars *Vars) (*CallTrace, *Response) {
+
 	trace := &CallTrace{}
 	execStart := time.Now()
 
@@ -284,7 +624,7 @@ func call(requestConfig *RequestConfig, rewriteConfig *RewriteConfig, suitePath
 	bodyTmpl, err := on.BodyContent(suitePath)
 	if err != nil {
 		trace.ErrorCause = err
-		return trace
+		return trace, nil
 	}
 
 	tmplCtx := NewTemplateContext(vars)
@@ -292,27 +632,70 @@ func call(requestConfig *RequestConfig, rewriteConfig *RewriteConfig, suitePath
 	bodyToSend := tmplCtx.ApplyTo(bodyTmpl)
 	if tmplCtx.HasErrors() {
 		trace.ErrorCause = tmplCtx.Error()
-		return trace
+		return trace, nil
 	}
 
-	req, err := populateRequest(requestConfig, on, bodyToSend, tmplCtx)
+	formBody, formContentType, err := on.FormContent(suitePath, tmplCtx)
 	if err != nil {
 		trace.ErrorCause = err
-		return trace
+		return trace, nil
+	}
+
+	if formContentType != "" {
+		bodyToSend = formBody
+	}
+
+	config := requestConfig
+	if call.Auth != nil {
+		config = config.withAuth(call.Auth)
+	}
+	if call.Sign != nil {
+		config = config.withSigning(call.Sign)
 	}
+	if call.Timeout != "" {
+		timeout, err := time.ParseDuration(call.Timeout)
+		if err != nil {
+			trace.ErrorCause = fmt.Errorf("invalid timeout: %s", call.Timeout)
+			return trace, nil
+		}
+		config = config.withTimeout(timeout)
+	}
+
+	req, err := populateRequest(config, on, bodyToSend, tmplCtx)
+	if err != nil {
+		trace.ErrorCause = err
+		return trace, nil
+	}
+
+	ctx, cancel := config.requestContext()
+	defer cancel() // response body is read within the time limit as well
+	req = req.WithContext(ctx)
+
+	if formContentType != "" {
+		req.Header.Set("Content-Type", formContentType)
+	} // boundary is generated, so explicit header is overridden
+
+	if config.Sign != nil {
+		err = config.Sign.sign(req, []byte(bodyToSend), tmplCtx)
+		if err != nil {
+			trace.ErrorCause = err
+			return trace, nil
+		}
+	} // signature covers the final request
 
 	trace.RequestDump = dumpRequest(req, bodyToSend, infoCurlFlag)
 	trace.RequestMethod = req.Method
 	trace.RequestURL = req.URL.String()
 
-	client := &http.Client{}
+	client := requestConfig.client()
 
 	resp, err := client.Do(req)
 
 	if err != nil {
 		debug.Print("Error when sending request", err)
-		trace.ErrorCause = err
-		return trace
+		trace.ErrorCause = config.timeoutError(ctx, err)
+		trace.ExecFrame = TimeFrame{Start: execStart, End: time.Now()}
+		return trace, nil
 	}
 
 	rewriteConfig.rewrite(resp)
@@ -320,50 +703,41 @@ func call(requestConfig *RequestConfig, rewriteConfig *RewriteConfig, suitePath
 	defer resp.Body.Close()
 
 	trace.ExecFrame = TimeFrame{Start: execStart, End: time.Now()}
+	trace.StatusCode = resp.StatusCode
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		debug.Print("Error reading response")
-		trace.ErrorCause = err
-		return trace
+		trace.ErrorCause = config.timeoutError(ctx, err)
+		return trace, nil
 	}
 
-	testResp := Response{http: resp, body: body}
+	testResp := &Response{http: resp, body: body, execFrame: trace.ExecFrame}
 	trace.ResponseDump = testResp.ToString()
 
 	if err = call.Expect.populateWith(vars); err != nil {
 		trace.ErrorCause = err
-		return trace
+		return trace, testResp
 	}
 
-	exps, err := expectations(call.Expect, suitePath)
+	exps, err := expectations(call.Expect, suitePath, config)
 	if err != nil {
 		trace.ErrorCause = err
-		return trace
+		return trace, testResp
 	}
 
 	for _, exp := range exps {
-		checkErr := exp.check(&testResp)
+		checkErr := exp.check(testResp)
 
 		if checkErr != nil {
 			trace.addFail(checkErr)
-			return trace
+			return trace, testResp
 		}
 
 		trace.addExp(exp.desc())
 	}
 
-	err = rememberBody(&testResp, call.Remember.BPath, vars)
-	debug.Print(vars)
-	if err != nil {
-		debug.Print("Error remember")
-		trace.ErrorCause = err
-		return trace
-	}
-
-	rememberHeaders(testResp.http.Header, call.Remember.Headers, vars)
-
-	return trace
+	return trace, testResp
 }
 
 func populateRequest(config *RequestConfig, on On, body string, tmplCtx *TemplateContext) (*http.Request, error) {
@@ -380,10 +754,24 @@ func populateRequest(config *RequestConfig, on On, body string, tmplCtx *Templat
 		return nil, err
 	}
 
+	// precedence: headers of the call, auth, --header and environment headers; signing is applied to the result
+	for k, v := range config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	callAuthorization := false
 	for key, valueTmpl := range on.Headers {
-		req.Header.Add(key, tmplCtx.ApplyTo(valueTmpl))
+		req.Header.Set(key, tmplCtx.ApplyTo(valueTmpl))
+		callAuthorization = callAuthorization || http.CanonicalHeaderKey(key) == "Authorization"
 	}
 
+	if config.Auth != nil && !callAuthorization {
+		err = config.Auth.apply(req, config, tmplCtx)
+		if err != nil {
+			return nil, err
+		}
+	} // explicit header of the call wins
+
 	q := req.URL.Query()
 	for key, valueTmpl := range on.Params {
 		q.Add(key, tmplCtx.ApplyTo(valueTmpl))
@@ -395,10 +783,6 @@ func populateRequest(config *RequestConfig, on On, body string, tmplCtx *Templat
 		return nil, tmplCtx.Error()
 	}
 
-	for k, v := range config.Headers {
-		req.Header.Add(k, v)
-	}
-
 	return req, nil
 }
 
@@ -418,14 +802,14 @@ func concatURL(base string, p string) (string, error) {
 	return baseURL.Scheme + "://" + baseURL.Host + path.Join(baseURL.Path, p), nil
 }
 
-func expectations(expect Expect, suitePath string) ([]ResponseExpectation, error) {
+func expectations(expect Expect, suitePath string, config *RequestConfig) ([]ResponseExpectation, error) {
 	var exps []ResponseExpectation
 	if expect.StatusCode != nil {
 		exps = append(exps, StatusCodeExpectation{statusCode: *expect.StatusCode})
 	}
 
 	if expect.BodySchemaURI != "" {
-		schema, err := expect.loadSchemaFromURI()
+		schema, err := expect.loadSchemaFromURI(config)
 		if err != nil {
 			return nil, err
 		}
@@ -433,6 +817,8 @@ func expectations(expect Expect, suitePath string) ([]ResponseExpectation, error
 		exps = append(exps, BodySchemaExpectation{
 			schema:      schema,
 			displayName: expect.BodySchemaURI,
+			location:    toAbsURL(hostFlag, expect.BodySchemaURI),
+			fetch:       config.fetch,
 		})
 	}
 
@@ -441,9 +827,17 @@ func expectations(expect Expect, suitePath string) ([]ResponseExpectation, error
 		if err != nil {
 			return nil, err
 		}
+
+		location, err := toAbsPath(suitePath, expect.BodySchemaFile)
+		if err != nil {
+			return nil, err
+		}
+
 		exps = append(exps, BodySchemaExpectation{
 			schema:      schema,
 			displayName: expect.BodySchemaFile,
+			location:    location,
+			fetch:       config.fetch,
 		})
 	}
 
@@ -451,6 +845,7 @@ func expectations(expect Expect, suitePath string) ([]ResponseExpectation, error
 		exps = append(exps, BodySchemaExpectation{
 			schema:      expect.BodySchemaRaw,
 			displayName: "",
+			fetch:       config.fetch,
 		})
 	}
 
@@ -480,6 +875,69 @@ func expectations(expect Expect, suitePath string) ([]ResponseExpectation, error
 		exps = append(exps, ContentTypeExpectation{expect.ContentType})
 	}
 
+	if expect.MaxDuration != "" {
+		maxDuration, err := time.ParseDuration(expect.MaxDuration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxDuration: %s", expect.MaxDuration)
+		}
+		exps = append(exps, MaxDurationExpectation{maxDuration: maxDuration})
+	}
+
+	if expect.OpenAPI != nil {
+		spec, err := expect.loadOpenAPISpec(suitePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if expect.OpenAPI.OperationID != "" && spec.OperationByID(expect.OpenAPI.OperationID) == nil {
+			return nil, fmt.Errorf("operation %s is not found in %s", expect.OpenAPI.OperationID, expect.OpenAPI.File)
+		}
+
+		exps = append(exps, OpenAPIExpectation{
+			spec:        spec,
+			operationID: expect.OpenAPI.OperationID,
+			displayName: expect.OpenAPI.File,
+		})
+	}
+
+	if expect.BodySize != nil {
+		exps = append(exps, BodySizeExpectation{min: expect.BodySize.Min, max: expect.BodySize.Max})
+	}
+
+	if expect.BodySha256 != "" {
+		exps = append(exps, BodySha256Expectation{sum: expect.BodySha256})
+	}
+
+	if expect.BodyEqualsFile != "" {
+		path, err := toAbsPath(suitePath, expect.BodyEqualsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("can't read body file: %s", err.Error())
+		}
+
+		exps = append(exps, BodyEqualsFileExpectation{content: content, displayName: expect.BodyEqualsFile})
+	}
+
+	if expect.BodyType != "" {
+		exps = append(exps, BodyTypeExpectation{mediaType: expect.BodyType})
+	}
+
+	for name, cookie := range expect.Cookies {
+		exps = append(exps, CookieExpectation{Name: name, Expected: cookie})
+	}
+
+	if expect.TLS != nil {
+		exp, err := newTLSExpectation(expect.TLS)
+		if err != nil {
+			return nil, err
+		}
+		exps = append(exps, exp)
+	}
+
 	// and so on
 	return exps, nil
 }
@@ -515,6 +973,77 @@ func rememberHeaders(header http.Header, remember map[string]string, vars *Vars)
 	}
 }
 
+func rememberCookies(cookies []*http.Cookie, remember map[string]string, vars *Vars) {
+	for valueName, cookieName := range remember {
+		for _, cookie := range cookies {
+			if cookie.Name == cookieName {
+				vars.Add(valueName, cookie.Value)
+				break
+			}
+		}
+	}
+}
+
+// tempBodyFiles are created to save response bodies without path, removed once the run is finished
+var tempBodyFiles = struct {
+	sync.Mutex
+	paths []string
+}{}
+
+func removeTempBodyFiles() {
+	tempBodyFiles.Lock()
+	defer tempBodyFiles.Unlock()
+
+	for _, path := range tempBodyFiles.paths {
+		os.Remove(path)
+	}
+	tempBodyFiles.paths = nil
+}
+
+// rememberBodyFile saves response body and remembers absolute path of the file.
+// Path is relative to the suite.
+func rememberBodyFile(resp *Response, remember *RememberBodyFile, suitePath string, vars *Vars) error {
+	tmplCtx := NewTemplateContext(vars)
+
+	path := tmplCtx.ApplyTo(remember.Path)
+	if tmplCtx.HasErrors() {
+		return tmplCtx.Error()
+	}
+
+	var err error
+	if path == "" {
+		file, err := ioutil.TempFile("", "bozr-body-*")
+		if err != nil {
+			return err
+		}
+		file.Close()
+
+		path = file.Name()
+
+		tempBodyFiles.Lock()
+		tempBodyFiles.paths = append(tempBodyFiles.paths, path)
+		tempBodyFiles.Unlock()
+	} else {
+		path, err = toAbsPath(suitePath, path)
+		if err != nil {
+			return err
+		}
+		path = filepath.FromSlash(path)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0777)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(path, resp.body, 0666)
+	if err != nil {
+		return fmt.Errorf("can't save response body: %s", err.Error())
+	}
+
+	return vars.Add(remember.Var, path)
+}
+
 func dumpRequest(req *http.Request, body string, dumpAsCurl bool) string {
 	if dumpAsCurl {
 		command, _ := http2curl.GetCurlCommand(req)
@@ -536,12 +1065,20 @@ func dumpRequest(req *http.Request, body string, dumpAsCurl bool) string {
 	return buf.String()
 }
 
-func terminate(msgLines ...string) {
+// exitOnPanic terminates with runtime error code, e.g. on failed report write.
+// Otherwise unrecovered panic exits with code 2 which means invalid suites.
+func exitOnPanic() {
+	if r := recover(); r != nil {
+		terminate(exitCodeRuntimeError, fmt.Sprintf("Unexpected error: %v", r))
+	}
+}
+
+func terminate(exitCode int, msgLines ...string) {
 	for _, line := range msgLines {
 		fmt.Fprintln(os.Stderr, line)
 	}
 
-	os.Exit(1)
+	os.Exit(exitCode)
 }
 
 func debugf(format string, v ...interface{}) {
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRememberBodyLazy(t *testing.T) {
@@ -37,7 +40,7 @@ func TestRunSuite_InvalidUrlAndUnused_OnlyInvalidUrl(t *testing.T) {
 		},
 	}
 
-	results := runSuite(&RequestConfig{}, &RewriteConfig{}, suite)
+	results := runSuite(&RunConfig{requestConfig: &RequestConfig{}, rewriteConfig: &RewriteConfig{}}, suite)
 
 	err := results[0].Traces[0].ErrorCause
 	if err == nil || !strings.Contains(err.Error(), "Invalid url") {
@@ -84,3 +87,291 @@ func TestConcatURL(t *testing.T) {
 	})
 
 }
+
+func TestCall_RetryUntilExpectationsPass(t *testing.T) {
+	initLogger()
+
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if hits < 3 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	statusCode := 200
+	c := Call{
+		On:     On{Method: "GET", URL: server.URL},
+		Expect: Expect{StatusCode: &statusCode},
+		Retry:  &Retry{Attempts: 5, Interval: "1ms"},
+	}
+
+	trace := call(&RequestConfig{}, &RewriteConfig{}, "", c, NewVars(""))
+
+	if trace.hasError() {
+		t.Fatal("unexpected error", trace.ErrorCause)
+	}
+
+	if len(trace.Attempts) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(trace.Attempts))
+	}
+
+	if trace.Attempts[0].StatusCode != http.StatusAccepted || trace.Attempts[0].ErrorCause == nil {
+		t.Errorf("unexpected first attempt: %+v", trace.Attempts[0])
+	}
+}
+
+func TestCall_RetryStopOnStatus(t *testing.T) {
+	initLogger()
+
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	statusCode := 200
+	c := Call{
+		On:     On{Method: "GET", URL: server.URL},
+		Expect: Expect{StatusCode: &statusCode},
+		Retry:  &Retry{Attempts: 5, StopOnStatus: []int{500}},
+	}
+
+	trace := call(&RequestConfig{}, &RewriteConfig{}, "", c, NewVars(""))
+
+	if !trace.hasError() || hits != 1 || len(trace.Attempts) != 1 {
+		t.Errorf("expected single failed attempt, hits: %d, attempts: %d, err: %v", hits, len(trace.Attempts), trace.ErrorCause)
+	}
+}
+
+func TestRunSuite_SetupAndTeardown(t *testing.T) {
+	initLogger()
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/login" {
+			w.Header().Set("X-Token", "secret")
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	statusCode := 200
+	get := func(path string) Call {
+		return Call{On: On{Method: "GET", URL: server.URL + path}, Expect: Expect{StatusCode: &statusCode}}
+	}
+
+	login := get("/login")
+	login.Remember = Remember{Headers: map[string]string{"token": "X-Token"}}
+
+	suite := TestSuite{
+		BeforeAll:  []Call{login},
+		BeforeEach: []Call{get("/before")},
+		AfterEach:  []Call{get("/after")},
+		AfterAll:   []Call{get("/logout")},
+		Cases: []TestCase{
+			{Name: "uses token", Calls: []Call{get("/data/{token}")}},
+			{Name: "fails", Calls: []Call{get("/fail")}},
+		},
+	}
+
+	results := runSuite(&RunConfig{requestConfig: &RequestConfig{}, rewriteConfig: &RewriteConfig{}}, suite)
+
+	var names []string
+	for _, result := range results {
+		names = append(names, result.Case.Name)
+	}
+
+	if actual := strings.Join(names, ", "); actual != "beforeAll, uses token, fails, afterAll" {
+		t.Fatalf("unexpected results: %s", actual)
+	}
+
+	for _, i := range []int{0, 1, 3} {
+		if results[i].hasError() || len(results[i].Traces) == 0 {
+			t.Errorf("%s: unexpected error: %s", results[i].Case.Name, results[i].Error())
+		}
+	}
+
+	if !results[2].hasError() {
+		t.Error("expected second case to fail")
+	}
+
+	expected := "/login /before /data/secret /after /before /fail /after /logout"
+	if actual := strings.Join(paths, " "); actual != expected {
+		t.Errorf("unexpected calls order. Expected: %s, Actual: %s", expected, actual)
+	}
+}
+
+func TestRunSuite_FailFast(t *testing.T) {
+	initLogger()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	statusCode := 200
+	get := Call{On: On{Method: "GET", URL: server.URL}, Expect: Expect{StatusCode: &statusCode}}
+
+	suite := TestSuite{
+		Cases: []TestCase{
+			{Name: "fails", Calls: []Call{get}},
+			{Name: "not executed", Calls: []Call{get}},
+		},
+	}
+
+	results := runSuite(&RunConfig{requestConfig: &RequestConfig{}, rewriteConfig: &RewriteConfig{}, failFast: true}, suite)
+
+	if len(results) != 1 || !results[0].hasError() {
+		t.Errorf("expected single failed result, got %d", len(results))
+	}
+}
+
+func TestRunSuite_CookieJarPerTestCase(t *testing.T) {
+	initLogger()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s-" + r.URL.Query().Get("user"), Path: "/", HttpOnly: true})
+			return
+		}
+
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-Session", cookie.Value)
+	}))
+	defer server.Close()
+
+	ok, unauthorized := 200, 401
+	login := func(user string) Call {
+		httpOnly := true
+		return Call{
+			On:       On{Method: "GET", URL: server.URL + "/login", Params: map[string]string{"user": user}},
+			Expect:   Expect{StatusCode: &ok, Cookies: map[string]CookieExpect{"session": {HTTPOnly: &httpOnly, Path: "/"}}},
+			Remember: Remember{Cookies: map[string]string{"session": "session"}},
+		}
+	}
+	profile := func(statusCode *int, session string) Call {
+		c := Call{On: On{Method: "GET", URL: server.URL + "/profile"}, Expect: Expect{StatusCode: statusCode}}
+		if session != "" {
+			c.Expect.Headers = map[string]string{"X-Session": session}
+		}
+		return c
+	}
+
+	withoutRemember := func(c Call) Call {
+		c.Remember = Remember{}
+		return c
+	}
+
+	disabled := false
+	suite := TestSuite{
+		Cases: []TestCase{
+			{Name: "john", Calls: []Call{login("john"), profile(&ok, "{session}")}},
+			{Name: "no cookies from other test case", Calls: []Call{profile(&unauthorized, "")}},
+			{Name: "opt-out", CookieJar: &disabled, Calls: []Call{withoutRemember(login("jane")), profile(&unauthorized, "")}},
+		},
+	}
+
+	results := runSuite(&RunConfig{requestConfig: &RequestConfig{}, rewriteConfig: &RewriteConfig{}}, suite)
+
+	for _, result := range results {
+		if result.hasError() {
+			t.Errorf("%s: unexpected error: %s", result.Case.Name, result.Error())
+		}
+	}
+}
+
+func TestRunSuite_Timeouts(t *testing.T) {
+	initLogger()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	statusCode := 200
+	get := func(timeout string) Call {
+		return Call{On: On{Method: "GET", URL: server.URL}, Expect: Expect{StatusCode: &statusCode}, Timeout: timeout}
+	}
+
+	suite := TestSuite{
+		Cases: []TestCase{
+			{Name: "default timeout", Calls: []Call{get("")}},
+			{Name: "test case timeout", Timeout: "1s", Calls: []Call{get("")}},
+			{Name: "call timeout", Timeout: "1s", Calls: []Call{get("50ms")}},
+		},
+	}
+
+	results := runSuite(&RunConfig{requestConfig: &RequestConfig{Timeout: 50 * time.Millisecond}, rewriteConfig: &RewriteConfig{}}, suite)
+
+	expected := []string{"request timed out after 50ms", "", "request timed out after 50ms"}
+	for i, result := range results {
+		if err := result.Error(); err != expected[i] {
+			t.Errorf("%s: expected error %#v, got %#v", result.Case.Name, expected[i], err)
+		}
+	}
+}
+
+func TestCall_RunTimeout(t *testing.T) {
+	initLogger()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})) // never answers
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	config := (&RequestConfig{}).withRunTimeout(ctx, 50*time.Millisecond)
+
+	trace := call(config, &RewriteConfig{}, "", Call{On: On{Method: "GET", URL: server.URL}}, NewVars(""))
+
+	if trace.ErrorCause == nil || trace.ErrorCause.Error() != "run timeout of 50ms exceeded" {
+		t.Errorf("expected run timeout error, got %v", trace.ErrorCause)
+	}
+}
+
+func TestCall_CookiesOfRedirectChain(t *testing.T) {
+	initLogger()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s-1", HttpOnly: true})
+			http.Redirect(w, r, "/home", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	ok, httpOnly := 200, true
+	c := Call{
+		On:       On{Method: "GET", URL: server.URL + "/login"},
+		Expect:   Expect{StatusCode: &ok, Cookies: map[string]CookieExpect{"session": {HTTPOnly: &httpOnly}}},
+		Remember: Remember{Cookies: map[string]string{"session": "session"}},
+	}
+
+	vars := NewVars("")
+	trace := call((&RequestConfig{}).withCookieJar(), &RewriteConfig{}, "", c, vars)
+
+	if trace.hasError() {
+		t.Fatal("unexpected error", trace.ErrorCause)
+	}
+
+	if vars.items["session"] != "s-1" {
+		t.Errorf("unexpected remembered session %v", vars.items["session"])
+	}
+}
@@ -19,6 +19,10 @@ const (
 // GetByPath returns value by exact path line
 func GetByPath(m interface{}, pathLine string) (interface{}, error) {
 
+	if err := validatePath(pathLine); err != nil {
+		return nil, err
+	}
+
 	res := Search(m, pathLine)
 
 	if len(res) != 1 {
@@ -43,6 +47,10 @@ func GetByPath(m interface{}, pathLine string) (interface{}, error) {
 func SearchByPath(m interface{}, expectedValue interface{}, pathLine string) error {
 	//fmt.Println("searchByPath", m, expectedValue, path, reflect.TypeOf(expectedValue))
 
+	if err := validatePath(pathLine); err != nil {
+		return err
+	}
+
 	resArr := Search(m, pathLine)
 
 	if HasPathFunc(pathLine) {
@@ -53,7 +61,7 @@ func SearchByPath(m interface{}, expectedValue interface{}, pathLine string) err
 
 		funcRes, err := CallPathFunc(pathLine, resArr[0])
 		if err == nil {
-			if funcRes == expectedValue {
+			if matchValue(expectedValue, funcRes) {
 				return nil
 			}
 			return fmt.Errorf("expected value %#v does not match actual %#v on path %#v", fmtExpectedValue(expectedValue), funcRes, pathLine)
@@ -62,6 +70,14 @@ func SearchByPath(m interface{}, expectedValue interface{}, pathLine string) err
 		return err
 	}
 
+	if ops, ok := asOperator(expectedValue); ok {
+		for _, res := range resArr {
+			if _, err := matchOperator(ops, res); err != nil {
+				return fmt.Errorf("invalid expectation on path %#v: %s", pathLine, err)
+			}
+		}
+	} // report misspelled operators instead of 'not found'
+
 	switch typedExpectedValue := expectedValue.(type) {
 	// single path have to match multiple expectations, e.g. items.id : [12,34,56]
 	case []interface{}:
@@ -89,6 +105,10 @@ func SearchByPath(m interface{}, expectedValue interface{}, pathLine string) err
 
 func fmtExpectedValue(expectedValue interface{}) string {
 
+	if _, ok := asOperator(expectedValue); ok {
+		return toJSON(expectedValue)
+	}
+
 	switch typedExpectedValue := expectedValue.(type) {
 	case bool:
 		return fmt.Sprintf("%t", typedExpectedValue)
@@ -106,6 +126,13 @@ func matchesAll(expectedMap map[string]interface{}, searchResult interface{}) bo
 	case map[string]interface{}:
 
 		for field := range expectedMap {
+			if ops, ok := asOperator(expectedMap[field]); ok {
+				if matched, _ := matchOperator(ops, typedSearchRes[field]); !matched {
+					return false
+				}
+				continue
+			}
+
 			expectedType := reflect.ValueOf(expectedMap[field]).Kind()
 			actualType := reflect.ValueOf(typedSearchRes[field]).Kind()
 			// no nested structures here:
@@ -133,6 +160,10 @@ func matchesAll(expectedMap map[string]interface{}, searchResult interface{}) bo
 // returns array of found results (array size = number of results)
 // each found result may have be any shape (array, map, value)
 func Search(m interface{}, pathLine string) []interface{} {
+	if IsJSONPath(pathLine) {
+		return searchJSONPath(m, pathLine)
+	}
+
 	path := cleanPath(pathLine)
 
 	res := make([]interface{}, 0)
@@ -141,6 +172,26 @@ func Search(m interface{}, pathLine string) []interface{} {
 	return res
 }
 
+func searchJSONPath(m interface{}, pathLine string) []interface{} {
+	p, err := CompileJSONPath(trimPathFunc(pathLine))
+	if err != nil {
+		debugf("%s", err)
+		return make([]interface{}, 0)
+	}
+
+	return p.Search(m)
+}
+
+// validatePath checks syntax of the path, only JSONPath expressions could be invalid
+func validatePath(pathLine string) error {
+	if !IsJSONPath(pathLine) {
+		return nil
+	}
+
+	_, err := CompileJSONPath(trimPathFunc(pathLine))
+	return err
+}
+
 func search(m interface{}, splitPath []string, res *[]interface{}) {
 	//fmt.Println(m, "~~~", splitPath)
 
@@ -155,6 +206,15 @@ func search(m interface{}, splitPath []string, res *[]interface{}) {
 		return
 	} // empty path elements do not lead anywhere
 
+	if transform, ok := pathTransforms[firstPathPart]; ok {
+		if transformed, err := transform(m); err == nil {
+			search(transformed, splitPath[1:], res)
+		} else {
+			debugf("%s", err)
+		}
+		return
+	}
+
 	switch typedM := m.(type) {
 	case map[string]interface{}:
 		if obj, ok := typedM[firstPathPart]; ok {
@@ -178,6 +238,18 @@ func search(m interface{}, splitPath []string, res *[]interface{}) {
 func findDeep(items []interface{}, expected interface{}) bool {
 	for _, item := range items {
 
+		if _, ok := asOperator(expected); ok {
+			if matchValue(expected, item) {
+				return true
+			}
+
+			if nested, ok := item.([]interface{}); ok && findDeep(nested, expected) {
+				return true
+			}
+
+			continue
+		} // operator could match array itself (e.g. $empty) or its items
+
 		switch typedItem := item.(type) {
 		case []interface{}:
 			found := findDeep(typedItem, expected)
@@ -230,7 +302,7 @@ func (e NewBodyMatcher) check(body interface{}) error {
 	r := new(bodyDiffReporter)
 	r.strict = e.Strict
 
-	opts := cmp.Options{r}
+	opts := cmp.Options{r, operatorComparer()}
 
 	_ = cmp.Equal(e.ExpectedBody, body, opts...)
 	diff := r.String()
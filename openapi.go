@@ -0,0 +1,507 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var pathParamRegexp = regexp.MustCompile(`\{[^{}/]+\}`)
+
+// OpenAPISpec is a subset of OpenAPI 3 document used to generate and verify calls.
+// All local references ($ref) are resolved while loading.
+type OpenAPISpec struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+
+	// operations in order of paths and methods
+	Operations []*OpenAPIOperation `json:"-"`
+}
+
+// OpenAPIOperation describes single method of the path
+type OpenAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary"`
+	Tags        []string                    `json:"tags"`
+	Parameters  []*OpenAPIParameter         `json:"parameters"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+
+	Method string `json:"-"`
+	Path   string `json:"-"`
+
+	pathPattern *regexp.Regexp
+}
+
+// OpenAPIParameter describes path, query, header or cookie parameter
+type OpenAPIParameter struct {
+	Name     string                 `json:"name"`
+	In       string                 `json:"in"`
+	Required bool                   `json:"required"`
+	Schema   map[string]interface{} `json:"schema"`
+	Example  interface{}            `json:"example"`
+}
+
+// OpenAPIRequestBody describes request body by media type
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse describes response of the particular status code
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Headers     map[string]*OpenAPIHeader    `json:"headers"`
+	Content     map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIHeader describes response header
+type OpenAPIHeader struct {
+	Required bool                   `json:"required"`
+	Schema   map[string]interface{} `json:"schema"`
+}
+
+// OpenAPIMediaType describes content of the particular media type
+type OpenAPIMediaType struct {
+	Schema  map[string]interface{} `json:"schema"`
+	Example interface{}            `json:"example"`
+}
+
+// loadOpenAPISpec reads OpenAPI 3 document in JSON or YAML format
+func loadOpenAPISpec(path string) (*OpenAPISpec, error) {
+	content, err := readSuiteFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read OpenAPI spec: %s", err)
+	}
+
+	var doc interface{}
+	err = json.Unmarshal(content, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse OpenAPI spec %s: %s", path, err)
+	}
+
+	resolved, err := resolveRefs(doc, doc, map[string]int{})
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec %s: %s", path, err)
+	}
+
+	content, _ = json.Marshal(resolved)
+
+	spec := &OpenAPISpec{}
+	err = json.Unmarshal(content, spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec %s: %s", path, err)
+	}
+
+	paths := make([]string, 0, len(spec.Paths))
+	for p := range spec.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		item := spec.Paths[p]
+
+		// parameters shared by all operations of the path
+		var shared []*OpenAPIParameter
+		if raw, ok := item["parameters"]; ok {
+			err = json.Unmarshal(raw, &shared)
+			if err != nil {
+				return nil, fmt.Errorf("invalid parameters of %s: %s", p, err)
+			}
+		}
+
+		for _, method := range openAPIMethods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+
+			op := &OpenAPIOperation{}
+			err = json.Unmarshal(raw, op)
+			if err != nil {
+				return nil, fmt.Errorf("invalid operation %s %s: %s", method, p, err)
+			}
+
+			op.Method = strings.ToUpper(method)
+			op.Path = p
+			op.Parameters = mergeParameters(shared, op.Parameters)
+			op.pathPattern = pathTemplatePattern(p)
+
+			spec.Operations = append(spec.Operations, op)
+		}
+	}
+
+	return spec, nil
+}
+
+// maxRecursiveRefs limits expansion of recursive references, e.g. user.manager.manager
+const maxRecursiveRefs = 3
+
+// resolveRefs replaces local references with referenced values.
+// Recursive references are expanded a few times and then replaced with empty (any value) schema.
+func resolveRefs(node interface{}, doc interface{}, resolving map[string]int) (interface{}, error) {
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := typed["$ref"].(string); ok {
+			if resolving[ref] >= maxRecursiveRefs {
+				return map[string]interface{}{}, nil
+			}
+
+			target, err := lookupRef(doc, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			resolving[ref]++
+			resolved, err := resolveRefs(target, doc, resolving)
+			resolving[ref]--
+
+			return resolved, err
+		}
+
+		result := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			resolved, err := resolveRefs(v, doc, resolving)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = resolved
+		}
+		return result, nil
+
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for i, v := range typed {
+			resolved, err := resolveRefs(v, doc, resolving)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = resolved
+		}
+		return result, nil
+	}
+
+	return node, nil
+}
+
+// lookupRef finds value by local reference, e.g. #/components/schemas/User
+func lookupRef(doc interface{}, ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("only local references are supported: %s", ref)
+	}
+
+	node := doc
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference: %s", ref)
+		}
+
+		node, ok = m[token]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference: %s", ref)
+		}
+	}
+
+	return node, nil
+}
+
+// mergeParameters returns operation parameters plus path level ones not overridden by operation
+func mergeParameters(shared, own []*OpenAPIParameter) []*OpenAPIParameter {
+	result := append([]*OpenAPIParameter{}, own...)
+
+	for _, s := range shared {
+		overridden := false
+		for _, o := range own {
+			if o.Name == s.Name && o.In == s.In {
+				overridden = true
+				break
+			}
+		}
+
+		if !overridden {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
+
+// pathTemplatePattern converts path template, e.g. /users/{id}, to regular expression
+func pathTemplatePattern(template string) *regexp.Regexp {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+
+	last := 0
+	for _, loc := range pathParamRegexp.FindAllStringIndex(template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		pattern.WriteString("[^/]+")
+		last = loc[1]
+	}
+
+	pattern.WriteString(regexp.QuoteMeta(strings.TrimSuffix(template[last:], "/")))
+	pattern.WriteString("/?$")
+
+	return regexp.MustCompile(pattern.String())
+}
+
+// FindOperation returns operation matching request method and path.
+// Path of the server url (e.g. /api/v1) is ignored.
+func (spec *OpenAPISpec) FindOperation(method, path string) *OpenAPIOperation {
+	candidates := []string{path}
+	for _, server := range spec.Servers {
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			continue
+		}
+
+		base := strings.TrimSuffix(u.Path, "/")
+		if base != "" && strings.HasPrefix(path, base) {
+			candidates = append(candidates, strings.TrimPrefix(path, base))
+		}
+	}
+
+	var found *OpenAPIOperation
+	for _, op := range spec.Operations {
+		if op.Method != strings.ToUpper(method) {
+			continue
+		}
+
+		for _, p := range candidates {
+			if !op.pathPattern.MatchString(p) {
+				continue
+			}
+
+			// literal segments win over templated ones, e.g. /users/me over /users/{id}
+			if found == nil || strings.Count(op.Path, "{") < strings.Count(found.Path, "{") {
+				found = op
+			}
+		}
+	}
+
+	return found
+}
+
+// OperationByID returns operation with specified id
+func (spec *OpenAPISpec) OperationByID(id string) *OpenAPIOperation {
+	for _, op := range spec.Operations {
+		if op.OperationID == id {
+			return op
+		}
+	}
+
+	return nil
+}
+
+// Name returns operation id or method and path if id is not defined
+func (op *OpenAPIOperation) Name() string {
+	if op.OperationID != "" {
+		return op.OperationID
+	}
+
+	return op.Method + " " + op.Path
+}
+
+// Response returns response documented for the status code, range (e.g. 2XX) or default one
+func (op *OpenAPIOperation) Response(statusCode int) (*OpenAPIResponse, string) {
+	code := strconv.Itoa(statusCode)
+
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if resp, ok := op.Responses[key]; ok {
+			return resp, key
+		}
+	}
+
+	return nil, ""
+}
+
+// SuccessStatusCode returns the lowest documented 2xx status code, 200 if none
+func (op *OpenAPIOperation) SuccessStatusCode() int {
+	best := 0
+	for key := range op.Responses {
+		code, err := strconv.Atoi(key)
+		if err != nil || code < 200 || code > 299 {
+			continue
+		}
+
+		if best == 0 || code < best {
+			best = code
+		}
+	}
+
+	if best == 0 {
+		return 200
+	}
+
+	return best
+}
+
+// JSONContent returns JSON media type of the content if present
+func JSONContent(content map[string]*OpenAPIMediaType) *OpenAPIMediaType {
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		mediaType := strings.ToLower(strings.TrimSpace(strings.Split(k, ";")[0]))
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			return content[k]
+		}
+	}
+
+	return nil
+}
+
+// toJSONSchema converts OpenAPI schema object to JSON schema.
+// OpenAPI specific keywords (e.g. nullable) are translated, documentation only ones are removed.
+func toJSONSchema(schema interface{}) interface{} {
+	switch typed := schema.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			switch k {
+			case "nullable", "example", "examples", "discriminator", "xml", "externalDocs", "deprecated", "readOnly", "writeOnly":
+				continue
+			case "properties", "patternProperties", "definitions", "$defs":
+				props := make(map[string]interface{})
+				if m, ok := v.(map[string]interface{}); ok {
+					for name, prop := range m {
+						props[name] = toJSONSchema(prop)
+					}
+				}
+				result[k] = props
+			default:
+				result[k] = toJSONSchema(v)
+			}
+		}
+
+		if nullable, _ := typed["nullable"].(bool); nullable {
+			if t, ok := typed["type"].(string); ok {
+				result["type"] = []interface{}{t, "null"}
+			}
+		}
+
+		return result
+
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for i, v := range typed {
+			result[i] = toJSONSchema(v)
+		}
+		return result
+	}
+
+	return schema
+}
+
+// exampleValue returns example of the value described by the schema
+func exampleValue(schema map[string]interface{}, example interface{}) interface{} {
+	return sampleValue(schema, example, 0)
+}
+
+func sampleValue(schema map[string]interface{}, example interface{}, depth int) interface{} {
+	if example != nil {
+		return example
+	}
+
+	for _, key := range []string{"example", "default"} {
+		if v, ok := schema[key]; ok {
+			return v
+		}
+	}
+
+	if values, ok := schema["enum"].([]interface{}); ok && len(values) > 0 {
+		return values[0]
+	}
+
+	for _, key := range []string{"allOf", "oneOf", "anyOf"} {
+		options, ok := schema[key].([]interface{})
+		if !ok || len(options) == 0 {
+			continue
+		}
+
+		if key != "allOf" {
+			option, _ := options[0].(map[string]interface{})
+			return sampleValue(option, nil, depth+1)
+		}
+
+		merged := map[string]interface{}{}
+		for _, option := range options {
+			optionSchema, _ := option.(map[string]interface{})
+			sample, ok := sampleValue(optionSchema, nil, depth+1).(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for k, v := range sample {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+
+	if depth > 5 {
+		return nil
+	} // recursive schemas
+
+	t, _ := schema["type"].(string)
+	if t == "" {
+		if _, ok := schema["properties"]; ok {
+			t = "object"
+		}
+	}
+
+	switch t {
+	case "object":
+		result := map[string]interface{}{}
+		props, _ := schema["properties"].(map[string]interface{})
+		for name, prop := range props {
+			propSchema, _ := prop.(map[string]interface{})
+			result[name] = sampleValue(propSchema, nil, depth+1)
+		}
+		return result
+
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		return []interface{}{sampleValue(items, nil, depth+1)}
+
+	case "integer":
+		return 1
+
+	case "number":
+		return 1.5
+
+	case "boolean":
+		return true
+
+	case "string":
+		switch schema["format"] {
+		case "date":
+			return "2020-01-01"
+		case "date-time":
+			return "2020-01-01T00:00:00Z"
+		case "uuid":
+			return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+		case "email":
+			return "user@example.com"
+		}
+		return "string"
+	}
+
+	return nil
+}
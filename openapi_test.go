@@ -0,0 +1,171 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const testOpenAPISpec = `
+openapi: 3.0.0
+servers:
+  - url: http://example.com/api/v1
+paths:
+  /users:
+    post:
+      operationId: createUser
+      tags: [Users]
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/User'
+      responses:
+        201:
+          description: created
+          headers:
+            Location:
+              required: true
+              schema:
+                type: string
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: integer
+          example: 42
+    get:
+      operationId: getUser
+      tags: [Users]
+      parameters:
+        - name: fields
+          in: query
+          required: true
+          schema:
+            type: string
+            enum: [name, email]
+      responses:
+        200:
+          description: found
+          content:
+            application/json; charset=utf-8:
+              schema:
+                $ref: '#/components/schemas/User'
+        404:
+          description: not found
+  /users/me:
+    get:
+      responses:
+        default:
+          description: current user
+components:
+  schemas:
+    User:
+      type: object
+      required: [name]
+      properties:
+        id:
+          type: integer
+          readOnly: true
+        name:
+          type: string
+          example: John
+        manager:
+          $ref: '#/components/schemas/User'
+        email:
+          type: string
+          nullable: true
+`
+
+func writeTestOpenAPISpec(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+
+	err := ioutil.WriteFile(path, []byte(testOpenAPISpec), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadOpenAPISpec(t *testing.T) {
+	spec, err := loadOpenAPISpec(writeTestOpenAPISpec(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(spec.Operations) != 3 {
+		t.Fatalf("unexpected operations %d", len(spec.Operations))
+	}
+
+	getUser := spec.OperationByID("getUser")
+	if getUser == nil || len(getUser.Parameters) != 2 {
+		t.Fatalf("path level parameters are not merged: %+v", getUser)
+	}
+
+	user := JSONContent(getUser.Responses["200"].Content)
+	if user == nil {
+		t.Fatal("json content is not found")
+	}
+
+	props := user.Schema["properties"].(map[string]interface{})
+	if _, ok := props["manager"].(map[string]interface{}); !ok {
+		t.Errorf("reference is not resolved: %v", props)
+	}
+}
+
+func TestOpenAPISpecFindOperation(t *testing.T) {
+	spec, err := loadOpenAPISpec(writeTestOpenAPISpec(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{method: "GET", path: "/users/42", want: "getUser"},
+		{method: "GET", path: "/api/v1/users/42", want: "getUser"},
+		{method: "GET", path: "/users/me", want: "GET /users/me"},
+		{method: "POST", path: "/users/", want: "createUser"},
+		{method: "DELETE", path: "/users/42", want: ""},
+		{method: "GET", path: "/users/42/orders", want: ""},
+	}
+
+	for _, tt := range tests {
+		op := spec.FindOperation(tt.method, tt.path)
+
+		name := ""
+		if op != nil {
+			name = op.Name()
+		}
+
+		if name != tt.want {
+			t.Errorf("%s %s: expected operation %#v, got %#v", tt.method, tt.path, tt.want, name)
+		}
+	}
+}
+
+func TestToJSONSchema_Nullable(t *testing.T) {
+	schema := toJSONSchema(map[string]interface{}{
+		"type":     "string",
+		"nullable": true,
+		"example":  "abc",
+	}).(map[string]interface{})
+
+	types, ok := schema["type"].([]interface{})
+	if !ok || len(types) != 2 || types[1] != "null" {
+		t.Errorf("unexpected type %v", schema["type"])
+	}
+
+	if _, ok := schema["example"]; ok {
+		t.Error("example is expected to be removed")
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// Operators could be used instead of exact expected values, e.g. "id": {"$regex": "^[0-9a-f]{32}$"}.
+// Several operators in one object must all match, e.g. {"$gt": 0, "$lt": 10}.
+type operatorFunc func(arg interface{}, actual interface{}) (bool, error)
+
+var operators map[string]operatorFunc
+
+func init() {
+	// initialized here to allow recursive references to matchValue
+	operators = map[string]operatorFunc{
+		"$regex":    regexOperator,
+		"$gt":       compareOperator(func(c int) bool { return c > 0 }),
+		"$gte":      compareOperator(func(c int) bool { return c >= 0 }),
+		"$lt":       compareOperator(func(c int) bool { return c < 0 }),
+		"$lte":      compareOperator(func(c int) bool { return c <= 0 }),
+		"$type":     typeOperator,
+		"$oneOf":    oneOfOperator,
+		"$not":      notOperator,
+		"$contains": containsOperator,
+		"$empty":    emptyOperator,
+		"$literal":  literalOperator,
+	}
+}
+
+// asOperator checks whether expected value is an operator object.
+// Objects with other keys (e.g. {"$ref": "#/x"}) are literal values.
+func asOperator(expected interface{}) (map[string]interface{}, bool) {
+	m, ok := expected.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		return nil, false
+	}
+
+	for k := range m {
+		if _, known := operators[k]; !known {
+			return nil, false
+		}
+	}
+
+	return m, true
+}
+
+// matchOperator checks actual value against all operators of the object
+func matchOperator(ops map[string]interface{}, actual interface{}) (bool, error) {
+	for name, arg := range ops {
+		op, ok := operators[name]
+		if !ok {
+			return false, fmt.Errorf("unknown operator %s", name)
+		}
+
+		matched, err := op(arg, actual)
+		if err != nil {
+			return false, fmt.Errorf("%s: %s", name, err)
+		}
+
+		if !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// matchValue checks actual value is equal to expected one or matches expected operator
+func matchValue(expected, actual interface{}) bool {
+	if ops, ok := asOperator(expected); ok {
+		matched, err := matchOperator(ops, actual)
+		if err != nil {
+			debugf("operator failed: %s", err)
+		}
+		return matched
+	}
+
+	return reflect.DeepEqual(expected, actual)
+}
+
+// operatorComparer makes body matching aware of operators.
+// Comparer must be symmetric, so operator could be on either side.
+func operatorComparer() cmp.Option {
+	isOperator := func(x, y interface{}) bool {
+		_, xok := asOperator(x)
+		_, yok := asOperator(y)
+		return xok || yok
+	}
+
+	compare := func(x, y interface{}) bool {
+		if _, ok := asOperator(x); ok {
+			return matchValue(x, y)
+		}
+		return matchValue(y, x)
+	}
+
+	return cmp.FilterValues(isOperator, cmp.Comparer(compare))
+}
+
+// literalOperator compares value as is, e.g. {"$literal": {"$type": "user"}} matches object with $type key
+func literalOperator(arg interface{}, actual interface{}) (bool, error) {
+	return reflect.DeepEqual(arg, actual), nil
+}
+
+func regexOperator(arg interface{}, actual interface{}) (bool, error) {
+	pattern, ok := arg.(string)
+	if !ok {
+		return false, fmt.Errorf("string pattern is expected, got %#v", arg)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	switch actual.(type) {
+	case string, float64, bool:
+		return re.MatchString(toString(actual)), nil
+	}
+
+	return false, nil
+}
+
+// compareOperator compares numbers, numeric strings (e.g. xml values) or strings (e.g. ISO dates)
+func compareOperator(accept func(c int) bool) operatorFunc {
+	return func(arg interface{}, actual interface{}) (bool, error) {
+		if af, ok := toNumber(actual); ok {
+			ef, ok := toNumber(arg)
+			if !ok {
+				return false, fmt.Errorf("number is expected, got %#v", arg)
+			}
+
+			c := 0
+			if af < ef {
+				c = -1
+			} else if af > ef {
+				c = 1
+			}
+			return accept(c), nil
+		}
+
+		as, aok := actual.(string)
+		es, eok := arg.(string)
+		if aok && eok {
+			return accept(strings.Compare(as, es)), nil
+		}
+
+		return false, nil
+	}
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch typed := v.(type) {
+	case float64:
+		return typed, true
+	case string:
+		f, err := strconv.ParseFloat(typed, 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+func typeOperator(arg interface{}, actual interface{}) (bool, error) {
+	expectedType, ok := arg.(string)
+	if !ok {
+		return false, fmt.Errorf("type name is expected, got %#v", arg)
+	}
+
+	return expectedType == typeName(actual) || (expectedType == "integer" && isInteger(actual)), nil
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+func isInteger(v interface{}) bool {
+	f, ok := v.(float64)
+	return ok && f == float64(int64(f))
+}
+
+func oneOfOperator(arg interface{}, actual interface{}) (bool, error) {
+	options, ok := arg.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("array is expected, got %#v", arg)
+	}
+
+	for _, option := range options {
+		if matchValue(option, actual) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func notOperator(arg interface{}, actual interface{}) (bool, error) {
+	return !matchValue(arg, actual), nil
+}
+
+func containsOperator(arg interface{}, actual interface{}) (bool, error) {
+	switch typed := actual.(type) {
+	case string:
+		sub, ok := arg.(string)
+		return ok && strings.Contains(typed, sub), nil
+
+	case []interface{}:
+		for _, item := range typed {
+			if matchValue(arg, item) {
+				return true, nil
+			}
+		}
+
+	case map[string]interface{}:
+		key, ok := arg.(string)
+		if ok {
+			_, found := typed[key]
+			return found, nil
+		}
+	}
+
+	return false, nil
+}
+
+func emptyOperator(arg interface{}, actual interface{}) (bool, error) {
+	expectEmpty, ok := arg.(bool)
+	if !ok {
+		return false, fmt.Errorf("boolean is expected, got %#v", arg)
+	}
+
+	empty := false
+	switch typed := actual.(type) {
+	case nil:
+		empty = true
+	case string:
+		empty = typed == ""
+	case []interface{}:
+		empty = len(typed) == 0
+	case map[string]interface{}:
+		empty = len(typed) == 0
+	}
+
+	return empty == expectEmpty, nil
+}
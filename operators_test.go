@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMatchOperator(t *testing.T) {
+	tests := []struct {
+		expected string
+		actual   string
+		match    bool
+	}{
+		{expected: `{"$regex": "^[0-9a-f]+$"}`, actual: `"12ab"`, match: true},
+		{expected: `{"$regex": "^[0-9a-f]+$"}`, actual: `"xyz"`, match: false},
+		{expected: `{"$gt": 5}`, actual: `6`, match: true},
+		{expected: `{"$gt": 5}`, actual: `5`, match: false},
+		{expected: `{"$gte": 5, "$lt": 10}`, actual: `5`, match: true},
+		{expected: `{"$gte": 5, "$lt": 10}`, actual: `10`, match: false},
+		{expected: `{"$lte": 10}`, actual: `"9"`, match: true}, // xml values are strings
+		{expected: `{"$lt": "2020-01-01"}`, actual: `"2019-12-31T10:00:00Z"`, match: true},
+		{expected: `{"$type": "string"}`, actual: `"abc"`, match: true},
+		{expected: `{"$type": "integer"}`, actual: `2`, match: true},
+		{expected: `{"$type": "integer"}`, actual: `2.5`, match: false},
+		{expected: `{"$type": "array"}`, actual: `[]`, match: true},
+		{expected: `{"$type": "null"}`, actual: `null`, match: true},
+		{expected: `{"$oneOf": ["new", "active"]}`, actual: `"active"`, match: true},
+		{expected: `{"$oneOf": ["new", {"$regex": "^act"}]}`, actual: `"active"`, match: true},
+		{expected: `{"$oneOf": ["new", "active"]}`, actual: `"closed"`, match: false},
+		{expected: `{"$not": "closed"}`, actual: `"active"`, match: true},
+		{expected: `{"$not": {"$empty": true}}`, actual: `""`, match: false},
+		{expected: `{"$contains": "ohn"}`, actual: `"John"`, match: true},
+		{expected: `{"$contains": 2}`, actual: `[1, 2, 3]`, match: true},
+		{expected: `{"$contains": "id"}`, actual: `{"id": 1}`, match: true},
+		{expected: `{"$empty": true}`, actual: `[]`, match: true},
+		{expected: `{"$empty": false}`, actual: `{}`, match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected+" "+tt.actual, func(t *testing.T) {
+			var expected, actual interface{}
+			json.Unmarshal([]byte(tt.expected), &expected)
+			json.Unmarshal([]byte(tt.actual), &actual)
+
+			if matchValue(expected, actual) != tt.match {
+				t.Errorf("Expected match: %v", tt.match)
+			}
+		})
+	}
+}
+
+func TestSearchByPath_Operators(t *testing.T) {
+	var body interface{}
+	json.Unmarshal([]byte(`{"users": [{"id": "a1", "age": 12}, {"id": "b2", "age": 40}], "tags": []}`), &body)
+
+	var expectations map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"users.id": {"$regex": "^b"},
+		"users.0.age": {"$lt": 18},
+		"users": {"id": {"$oneOf": ["x", "a1"]}},
+		"users.size()": {"$gte": 2},
+		"tags": {"$empty": true}
+	}`), &expectations)
+
+	for path, expected := range expectations {
+		if err := SearchByPath(body, expected, path); err != nil {
+			t.Error(err)
+		}
+	}
+
+	err := SearchByPath(body, map[string]interface{}{"$regx": "^b"}, "users.id")
+	if err == nil {
+		t.Error("object with unknown operator is expected to be compared as literal")
+	}
+}
+
+func TestBodyMatcher_LiteralDollarKeys(t *testing.T) {
+	var actual, expected interface{}
+	json.Unmarshal([]byte(`{"schema": {"$ref": "#/x"}, "item": {"$type": "user"}}`), &actual)
+	json.Unmarshal([]byte(`{"schema": {"$ref": "#/x"}, "item": {"$literal": {"$type": "user"}}}`), &expected)
+
+	if err := (NewBodyMatcher{ExpectedBody: expected}).check(actual); err != nil {
+		t.Error(err)
+	}
+
+	json.Unmarshal([]byte(`{"schema": {"$ref": "#/y"}}`), &expected)
+
+	if err := (NewBodyMatcher{ExpectedBody: expected}).check(actual); err == nil {
+		t.Error("expected body mismatch of literal $ref")
+	}
+}
+
+func TestBodyMatcher_Operators(t *testing.T) {
+	var actual, expected interface{}
+	json.Unmarshal([]byte(`{"id": "5f1c", "createdAt": "2021-01-01", "items": [{"price": 10}]}`), &actual)
+	json.Unmarshal([]byte(`{"id": {"$type": "string"}, "items": [{"price": {"$gt": 0}}]}`), &expected)
+
+	if err := (NewBodyMatcher{ExpectedBody: expected}).check(actual); err != nil {
+		t.Error(err)
+	}
+
+	json.Unmarshal([]byte(`{"id": {"$regex": "^[0-9]+$"}}`), &expected)
+
+	err := (NewBodyMatcher{ExpectedBody: expected}).check(actual)
+	if err == nil || !strings.Contains(err.Error(), `{"$regex":"^[0-9]+$"}`) {
+		t.Error("expected body mismatch, got", err)
+	}
+}
@@ -1,28 +1,79 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"sync"
+	"time"
 )
 
 // RunSuiteFunc describes particular test suite execution. Passed here to deleniate parallelism from suite execution logic
-type RunSuiteFunc func(requestConfig *RequestConfig, rewriteConfig *RewriteConfig, suite TestSuite) []TestResult
+type RunSuiteFunc func(runConfig *RunConfig, suite TestSuite) []TestResult
 
-// RunParallel starts parallel routines to execute test suites received from loader channel
-func RunParallel(runConfig *RunConfig) {
+// RunTimeoutError is returned when the whole run is not completed in time
+type RunTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e RunTimeoutError) Error() string {
+	return fmt.Sprintf("run timeout of %s exceeded, the rest of the tests are not executed", e.Timeout)
+}
+
+// RunParallel starts parallel routines to execute test suites received from loader channel.
+// Returns false if any test case is failed, RunTimeoutError if the run timeout is exceeded
+// and an error if execution of a suite is terminated unexpectedly.
+func RunParallel(runConfig *RunConfig) (bool, error) {
 
 	resultConsumer := make(chan []TestResult)
 
+	var stopOnce sync.Once
+	stop := func() {
+		if runConfig.stop != nil {
+			stopOnce.Do(func() { close(runConfig.stop) })
+		}
+	}
+
+	var (
+		runErr  error
+		errOnce sync.Once
+	)
+	fail := func(err error) {
+		errOnce.Do(func() { runErr = err })
+		stop()
+	}
+
+	requestConfig := runConfig.requestConfig
+	runCtx := context.Background()
+	if runConfig.timeout > 0 {
+		ctx, cancel := context.WithTimeout(runCtx, runConfig.timeout)
+		defer cancel()
+
+		runCtx = ctx
+		requestConfig = requestConfig.withRunTimeout(ctx, runConfig.timeout)
+
+		go func() {
+			<-ctx.Done()
+			if ctx.Err() == context.DeadlineExceeded {
+				stop()
+			}
+		}() // requests in progress are cancelled by the context, the rest suites are not started
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(runConfig.numRoutines)
 
+	suiteRunConfig := *runConfig
+	suiteRunConfig.requestConfig = requestConfig
+
 	for i := 0; i < runConfig.numRoutines; i++ {
 		go runSuites(&SuiteConfig{
-			requestConfig:  runConfig.requestConfig,
-			rewriteConfig:  runConfig.rewriteConfig,
+			runConfig:      &suiteRunConfig,
 			loader:         runConfig.loader,
 			resultConsumer: resultConsumer,
 			waitGroup:      &wg,
 			runner:         runConfig.runSuite,
+			stop:           runConfig.stop,
+			fail:           fail,
 		})
 	}
 
@@ -33,33 +84,85 @@ func RunParallel(runConfig *RunConfig) {
 		close(resultConsumer)
 	}()
 
+	passed := true
+
 	for {
 		results, more := <-resultConsumer
 
 		runConfig.reporter.Report(results)
 
+		if hasFailed(results) {
+			passed = false
+
+			if runConfig.failFast {
+				stop()
+			}
+		}
+
 		if !more {
 			break
 		}
 	}
 
 	runConfig.reporter.Flush()
+
+	if runErr != nil {
+		return false, runErr
+	}
+
+	if runCtx.Err() == context.DeadlineExceeded {
+		return false, RunTimeoutError{Timeout: runConfig.timeout}
+	}
+
+	return passed, nil
+}
+
+func hasFailed(results []TestResult) bool {
+	for _, result := range results {
+		if !result.Skipped && result.hasError() {
+			return true
+		}
+	}
+
+	return false
 }
 
 type SuiteConfig struct {
-	requestConfig  *RequestConfig
-	rewriteConfig  *RewriteConfig
+	runConfig      *RunConfig
 	loader         <-chan TestSuite
 	resultConsumer chan []TestResult
 	waitGroup      *sync.WaitGroup
 	runner         RunSuiteFunc
+	stop           <-chan struct{}
+	// stops the run with the error
+	fail func(err error)
 }
 
 func runSuites(cfg *SuiteConfig) {
+	defer cfg.waitGroup.Done()
 
 	for suite := range cfg.loader {
-		cfg.resultConsumer <- runSuite(cfg.requestConfig, cfg.rewriteConfig, suite)
+		select {
+		case <-cfg.stop:
+			return
+		default:
+		}
+
+		cfg.resultConsumer <- runSuiteRecovered(cfg, suite)
 	}
+}
+
+// runSuiteRecovered reports panic of the suite execution as failed suite and stops the run,
+// so results of the rest suites are still reported and flushed.
+func runSuiteRecovered(cfg *SuiteConfig, suite TestSuite) (results []TestResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("unexpected error: %v", r)
+			cfg.fail(err)
+
+			results = append(results, TestResult{Suite: suite, Case: TestCase{Name: suite.Name}, Traces: []*CallTrace{{ErrorCause: err}}})
+		}
+	}()
 
-	cfg.waitGroup.Done()
+	return cfg.runner(cfg.runConfig, suite)
 }
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type countingReporter struct {
+	results int
+	flushed bool
+}
+
+func (r *countingReporter) Init() {}
+
+func (r *countingReporter) Report(results []TestResult) {
+	r.results += len(results)
+}
+
+func (r *countingReporter) Flush() {
+	r.flushed = true
+}
+
+func TestRunParallel_FailFast(t *testing.T) {
+	// given
+	loader := make(chan TestSuite)
+	stop := make(chan struct{})
+
+	go func() {
+		defer close(loader)
+		for i := 0; i < 10; i++ {
+			select {
+			case loader <- TestSuite{Name: "suite"}:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	failing := func(runConfig *RunConfig, suite TestSuite) []TestResult {
+		return []TestResult{{Suite: suite, Traces: []*CallTrace{{ErrorCause: errors.New("failed")}}}}
+	}
+
+	reporter := &countingReporter{}
+
+	// when
+	passed, _ := RunParallel(&RunConfig{
+		loader:      loader,
+		reporter:    reporter,
+		runSuite:    failing,
+		numRoutines: 1,
+		failFast:    true,
+		stop:        stop,
+	})
+
+	// then
+	if passed {
+		t.Error("run is expected to fail")
+	}
+
+	if !reporter.flushed {
+		t.Error("reporter is not flushed")
+	}
+
+	if reporter.results >= 10 {
+		t.Errorf("execution is not stopped after first failure, results reported: %d", reporter.results)
+	}
+}
+
+func TestRunParallel_Passed(t *testing.T) {
+	// given
+	loader := make(chan TestSuite, 2)
+	loader <- TestSuite{Name: "a"}
+	loader <- TestSuite{Name: "b"}
+	close(loader)
+
+	passing := func(runConfig *RunConfig, suite TestSuite) []TestResult {
+		return []TestResult{{Suite: suite, Traces: []*CallTrace{{}}}}
+	}
+
+	reporter := &countingReporter{}
+
+	// when
+	passed, err := RunParallel(&RunConfig{loader: loader, reporter: reporter, runSuite: passing, numRoutines: 2})
+
+	// then
+	if !passed || err != nil || reporter.results != 2 {
+		t.Errorf("unexpected run outcome: passed %v, err %v, results %d", passed, err, reporter.results)
+	}
+}
+
+func TestRunParallel_RunTimeout(t *testing.T) {
+	// given
+	loader := make(chan TestSuite)
+	stop := make(chan struct{})
+
+	go func() {
+		defer close(loader)
+		for i := 0; i < 10; i++ {
+			select {
+			case loader <- TestSuite{Name: "suite"}:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	hanging := func(runConfig *RunConfig, suite TestSuite) []TestResult {
+		<-runConfig.requestConfig.runContext().Done()
+		return []TestResult{{Suite: suite, Traces: []*CallTrace{{}}}}
+	}
+
+	reporter := &countingReporter{}
+
+	// when
+	passed, err := RunParallel(&RunConfig{
+		loader:        loader,
+		requestConfig: &RequestConfig{},
+		reporter:      reporter,
+		runSuite:      hanging,
+		numRoutines:   2,
+		stop:          stop,
+		timeout:       50 * time.Millisecond,
+	})
+
+	// then
+	if passed || err != (RunTimeoutError{Timeout: 50 * time.Millisecond}) {
+		t.Errorf("run is expected to fail with run timeout, got %v", err)
+	}
+
+	if !reporter.flushed {
+		t.Error("reporter is not flushed")
+	}
+
+	if reporter.results >= 10 {
+		t.Errorf("execution is not stopped after run timeout, results reported: %d", reporter.results)
+	}
+}
+
+func TestRunParallel_PanicIsReported(t *testing.T) {
+	// given
+	loader := make(chan TestSuite, 1)
+	loader <- TestSuite{Name: "suite"}
+	close(loader)
+
+	panicking := func(runConfig *RunConfig, suite TestSuite) []TestResult {
+		panic("boom")
+	}
+
+	reporter := &countingReporter{}
+
+	// when
+	passed, err := RunParallel(&RunConfig{loader: loader, reporter: reporter, runSuite: panicking, numRoutines: 1})
+
+	// then
+	if passed || err == nil || err.Error() != "unexpected error: boom" {
+		t.Errorf("run is expected to fail with unexpected error, got %v", err)
+	}
+
+	if !reporter.flushed || reporter.results != 1 {
+		t.Errorf("failed suite is not reported, flushed %v, results %d", reporter.flushed, reporter.results)
+	}
+}
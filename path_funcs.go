@@ -11,6 +11,14 @@ var (
 		"size()":         size,
 		"string()":       str,
 		"sizeAsString()": sizeAsStr,
+		"jwt()":          jwtClaims,
+		"jwtHeader()":    jwtHeader,
+	}
+
+	// functions which could be used in the middle of the path, e.g. access_token.jwt().sub
+	pathTransforms = map[string]pathFunc{
+		"jwt()":       jwtClaims,
+		"jwtHeader()": jwtHeader,
 	}
 )
 
@@ -42,6 +50,17 @@ func CallPathFunc(pathLine string, arg interface{}) (interface{}, error) {
 	return nil, fmt.Errorf("no function declarations found on path %#v", pathLine)
 }
 
+// trimPathFunc removes suffix function from pathLine
+func trimPathFunc(pathLine string) string {
+	for fname := range pathFuncs {
+		if strings.HasSuffix(pathLine, "."+fname) {
+			return strings.TrimSuffix(pathLine, "."+fname)
+		}
+	}
+
+	return pathLine
+}
+
 type pathFunc func(arg interface{}) (interface{}, error)
 
 func size(arg interface{}) (interface{}, error) {
@@ -68,3 +87,11 @@ func sizeAsStr(arg interface{}) (interface{}, error) {
 
 	return toString(numSize), nil
 }
+
+func jwtClaims(arg interface{}) (interface{}, error) {
+	return decodeJWT(arg, 1)
+}
+
+func jwtHeader(arg interface{}) (interface{}, error) {
+	return decodeJWT(arg, 0)
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// skippedRecordHeaders are not written to the suite, they are set by the client or transport
+var skippedRecordHeaders = map[string]bool{
+	"Accept-Encoding":   true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Host":              true,
+	"User-Agent":        true,
+	"X-Forwarded-For":   true,
+	"X-Forwarded-Host":  true,
+	"X-Forwarded-Proto": true,
+}
+
+// credentialHeaders are not written to suites and reports as is, so live credentials are not committed or shared
+var credentialHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// credentialPlaceholder returns header value with credential replaced by the placeholder (built from the header name)
+// if the header is a credential, e.g. "{env:X_API_KEY}" for recorded suites or "***" for reports.
+// Authorization scheme is kept, e.g. "Bearer {env:AUTHORIZATION}".
+func credentialPlaceholder(key, value string, placeholder func(name string) string) (string, bool) {
+	key = http.CanonicalHeaderKey(key)
+
+	if !credentialHeaders[key] {
+		lower := strings.ToLower(key)
+		if !strings.Contains(lower, "token") && !strings.Contains(lower, "secret") && !strings.Contains(lower, "signature") &&
+			!strings.Contains(lower, "api-key") && !strings.Contains(lower, "apikey") {
+			return "", false
+		}
+	}
+
+	name := strings.ToUpper(strings.Replace(key, "-", "_", -1))
+
+	if key == "Authorization" || key == "Proxy-Authorization" {
+		switch scheme := strings.SplitN(value, " ", 2)[0]; strings.ToLower(scheme) {
+		case "bearer", "basic":
+			return scheme + " " + placeholder(name), true
+		}
+	}
+
+	return placeholder(name), true
+}
+
+// envPlaceholder refers environment variable, so the recorded suite could be replayed
+func envPlaceholder(name string) string {
+	return "{" + envVarPrefix + varPrefixSeparator + name + "}"
+}
+
+// callDefinition is a serialized form of the call written to generated suites
+type callDefinition struct {
+	On       On        `json:"on"`
+	Expect   Expect    `json:"expect"`
+	Remember *Remember `json:"remember,omitempty"`
+}
+
+// testCaseDefinition is a serialized form of the test case written to generated suites
+type testCaseDefinition struct {
+	Name  string            `json:"name"`
+	Calls []*callDefinition `json:"calls"`
+}
+
+// Recorder converts proxied exchanges into calls of a single test case
+type Recorder struct {
+	// suite file to write
+	OutPath string
+
+	testCase testCaseDefinition
+	// remembered values by variable name and the calls they are remembered in
+	ids     map[string]string
+	sources map[string]*callDefinition
+	mutex   sync.Mutex
+}
+
+// NewRecorder creates recorder of the test case with specified name
+func NewRecorder(outPath, caseName string) *Recorder {
+	return &Recorder{
+		OutPath:  outPath,
+		testCase: testCaseDefinition{Name: caseName, Calls: []*callDefinition{}},
+		ids:      make(map[string]string),
+		sources:  make(map[string]*callDefinition),
+	}
+}
+
+// Add records exchange as a call and rewrites suite file
+func (r *Recorder) Add(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	call := &callDefinition{
+		On: On{
+			Method: req.Method,
+			URL:    r.withPlaceholders(req.URL.Path),
+		},
+	}
+
+	for key, values := range req.URL.Query() {
+		if call.On.Params == nil {
+			call.On.Params = make(map[string]string)
+		}
+		call.On.Params[key] = r.withPlaceholders(values[0])
+	}
+
+	for key := range req.Header {
+		if skippedRecordHeaders[key] {
+			continue
+		}
+		if call.On.Headers == nil {
+			call.On.Headers = make(map[string]string)
+		}
+		value := req.Header.Get(key)
+		if placeholder, ok := credentialPlaceholder(key, value, envPlaceholder); ok {
+			value = placeholder
+		}
+		call.On.Headers[key] = value
+	}
+
+	if len(reqBody) > 0 {
+		body := r.withPlaceholders(string(reqBody))
+		if json.Valid([]byte(body)) && strings.HasPrefix(strings.TrimSpace(body), "{") {
+			call.On.Body = json.RawMessage(body)
+		} else {
+			call.On.Body, _ = json.Marshal(body)
+		}
+	}
+
+	statusCode := resp.StatusCode
+	call.Expect.StatusCode = &statusCode
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err == nil {
+		call.Expect.ContentType = mediaType
+	}
+
+	var body map[string]interface{}
+	if json.Unmarshal(respBody, &body) == nil && len(body) > 0 {
+		call.Expect.ExactBody = body
+		r.addIds(call, body)
+	} // only objects are supported by exactBody
+
+	r.testCase.Calls = append(r.testCase.Calls, call)
+
+	return r.write()
+}
+
+// withPlaceholders replaces ids from previous responses with placeholders.
+// Responses the ids are taken from get remember hints.
+func (r *Recorder) withPlaceholders(str string) string {
+	names := make([]string, 0, len(r.ids))
+	for name := range r.ids {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := r.ids[name]
+
+		replaced := str
+		if strings.HasPrefix(str, "/") {
+			replaced = replacePathSegment(str, value, "{"+name+"}")
+		} else if str == value {
+			replaced = "{" + name + "}"
+		} else if strings.Contains(str, `"`+value+`"`) {
+			replaced = strings.Replace(str, `"`+value+`"`, `"{`+name+`}"`, -1)
+		}
+
+		if replaced == str {
+			continue
+		}
+
+		str = replaced
+
+		source := r.sources[name]
+		if source.Remember == nil {
+			source.Remember = &Remember{BPath: make(map[string]string)}
+		}
+		source.Remember.BPath[name] = name
+	}
+
+	return str
+}
+
+func replacePathSegment(path, value, placeholder string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment == value {
+			segments[i] = placeholder
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// addIds registers top level id fields of the response, e.g. id, userId, order_id
+func (r *Recorder) addIds(call *callDefinition, body map[string]interface{}) {
+	for key, value := range body {
+		lower := strings.ToLower(key)
+		if lower != "id" && !strings.HasSuffix(key, "Id") && !strings.HasSuffix(lower, "_id") {
+			continue
+		}
+
+		var str string
+		switch typed := value.(type) {
+		case string:
+			str = typed
+		case float64:
+			str = toString(typed)
+		default:
+			continue
+		}
+
+		if len(str) < 2 {
+			continue
+		} // too short to be replaced reliably
+
+		if _, ok := r.ids[key]; ok {
+			continue
+		} // the first response with the key wins
+
+		r.ids[key] = str
+		r.sources[key] = call
+	}
+}
+
+func (r *Recorder) write() error {
+	content, err := json.MarshalIndent([]testCaseDefinition{r.testCase}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(r.OutPath), 0777)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.OutPath, content, 0666)
+}
+
+// NewRecordingProxy creates reverse proxy to the target which records every exchange
+func NewRecordingProxy(target *url.URL, recorder *Recorder) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
+		req.Header.Del("Accept-Encoding") // record plain bodies
+	}
+
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		respBody, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+		incoming, ok := resp.Request.Context().Value(incomingRequestKey{}).(*incomingRequest)
+		if !ok {
+			return nil
+		}
+
+		err = recorder.Add(incoming.req, incoming.body, resp, respBody)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Cannot record call:", err)
+		}
+
+		fmt.Printf("%-7s %s %d\n", incoming.req.Method, incoming.req.URL.RequestURI(), resp.StatusCode)
+		return nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		// path is recorded relative to the target, before proxy joins them
+		incoming := &incomingRequest{req: req.Clone(req.Context()), body: body}
+		ctx := context.WithValue(req.Context(), incomingRequestKey{}, incoming)
+
+		proxy.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+type incomingRequestKey struct{}
+
+type incomingRequest struct {
+	req  *http.Request
+	body []byte
+}
+
+// recordCommand starts reverse proxy which writes exchanges to suite file
+func recordCommand(args []string) {
+	flags := flag.NewFlagSet("record", flag.ExitOnError)
+	target := flags.String("target", "", "Upstream URL to forward requests to")
+	out := flags.String("out", ".", "Directory to write suite file to")
+	name := flags.String("name", "recorded", "Name of the suite file")
+	addr := flags.String("addr", ":8080", "Address to listen on")
+	flags.BoolVar(&debugFlag, "d", false, "Enable debug mode")
+	flags.BoolVar(&debugFlag, "debug", false, "Enable debug mode")
+	flags.Usage = func() {
+		h := "Usage:\n"
+		h += "  bozr record --target URL [OPTIONS]\n\n"
+
+		h += "Options:\n"
+		h += "      --target  Upstream URL to forward requests to\n"
+		h += "      --out     Directory to write suite file to. Default is current directory\n"
+		h += "      --name    Name of the suite file. Default is recorded\n"
+		h += "      --addr    Address to listen on. Default is :8080\n"
+		h += "  -d, --debug   Enable debug mode\n"
+
+		fmt.Fprint(os.Stderr, h)
+	}
+
+	flags.Parse(args)
+
+	initLogger()
+
+	targetURL, err := url.ParseRequestURI(*target)
+	if err != nil || targetURL.Host == "" {
+		flags.Usage()
+		fmt.Println()
+		terminate(exitCodeRuntimeError, "You must specify valid target URL.")
+		return
+	}
+
+	outPath := filepath.Join(*out, *name+suiteExts[0])
+	if _, err := os.Stat(outPath); err == nil {
+		terminate(exitCodeRuntimeError, fmt.Sprintf("Suite %s already exists.", outPath))
+		return
+	}
+
+	recorder := NewRecorder(outPath, "Recorded at "+time.Now().Format("2006-01-02 15:04:05"))
+
+	fmt.Printf("Recording %s to %s on %s\n\n", targetURL, outPath, *addr)
+
+	err = http.ListenAndServe(*addr, NewRecordingProxy(targetURL, recorder))
+	if err != nil {
+		terminate(exitCodeRuntimeError, err.Error())
+	}
+}
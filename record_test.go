@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordingProxy(t *testing.T) {
+	// given
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/users":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": "u-123", "name": "John"}`))
+		case r.Method == "GET" && r.URL.Path == "/api/users/u-123":
+			w.Write([]byte(`{"id": "u-123", "name": "John"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer upstream.Close()
+
+	target, _ := url.Parse(upstream.URL + "/api")
+	outPath := filepath.Join(t.TempDir(), "recorded.suite.json")
+
+	proxy := httptest.NewServer(NewRecordingProxy(target, NewRecorder(outPath, "Recorded")))
+	defer proxy.Close()
+
+	// when
+	resp, err := http.Post(proxy.URL+"/users", "application/json", strings.NewReader(`{"name": "John"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	req, _ := http.NewRequest("GET", proxy.URL+"/users/u-123?fields=name", nil)
+	req.Header.Set("Authorization", "Bearer live-token")
+	req.Header.Set("Cookie", "session=live")
+	req.Header.Set("X-Request-Id", "r-1")
+
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// then
+	err = validateSuite(outPath)
+	if err != nil {
+		t.Fatalf("recorded suite is invalid: %s", err)
+	}
+
+	content, _ := ioutil.ReadFile(outPath)
+
+	var cases []testCaseDefinition
+	err = json.Unmarshal(content, &cases)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := cases[0].Calls
+	if len(calls) != 2 {
+		t.Fatalf("unexpected calls %s", content)
+	}
+
+	if calls[0].On.URL != "/users" || *calls[0].Expect.StatusCode != 201 || calls[0].Expect.ContentType != "application/json" {
+		t.Errorf("unexpected first call %s", content)
+	}
+
+	if calls[0].Remember == nil || calls[0].Remember.BPath["id"] != "id" {
+		t.Errorf("id is not remembered %s", content)
+	}
+
+	if calls[1].On.URL != "/users/{id}" || calls[1].On.Params["fields"] != "name" {
+		t.Errorf("unexpected second call %s", content)
+	}
+
+	headers := calls[1].On.Headers
+	if headers["Authorization"] != "Bearer {env:AUTHORIZATION}" || headers["Cookie"] != "{env:COOKIE}" || headers["X-Request-Id"] != "r-1" {
+		t.Errorf("unexpected headers %v", headers)
+	}
+
+	t.Setenv("AUTHORIZATION", "replay-token")
+	if replayed := NewVars("").ApplyTo(headers["Authorization"]); replayed != "Bearer replay-token" {
+		t.Errorf("credential is not taken from environment on replay: %s", replayed)
+	}
+
+	if strings.Contains(string(content), "live") {
+		t.Errorf("credentials are written to the suite %s", content)
+	}
+}
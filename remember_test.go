@@ -2,6 +2,9 @@ package main
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -69,3 +72,51 @@ func TestRememberHeader(t *testing.T) {
 		t.Errorf("Unexpected remembered value: %s", vars.items["valueKey"])
 	}
 }
+
+func TestRememberBodyFile(t *testing.T) {
+	initLogger()
+
+	dir := t.TempDir()
+	body := []byte("%PDF-1.4")
+
+	vars := NewVars("")
+	vars.Add("id", "42")
+
+	err := rememberBodyFile(&Response{body: body}, &RememberBodyFile{Path: "docs/{id}.pdf", Var: "doc"}, dir, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "docs", "42.pdf")
+	if vars.items["doc"] != path {
+		t.Errorf("Unexpected remembered path: %s", vars.items["doc"])
+	}
+
+	saved, err := ioutil.ReadFile(path)
+	if err != nil || !bytes.Equal(saved, body) {
+		t.Errorf("Unexpected saved body: %s, %v", saved, err)
+	}
+}
+
+func TestRememberBodyTempFile(t *testing.T) {
+	vars := NewVars("")
+
+	err := rememberBodyFile(&Response{body: []byte("data")}, &RememberBodyFile{Var: "doc"}, "", vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, _ := vars.items["doc"].(string)
+	defer os.Remove(path)
+
+	saved, err := ioutil.ReadFile(path)
+	if err != nil || string(saved) != "data" {
+		t.Errorf("Unexpected saved body: %s, %v", saved, err)
+	}
+
+	removeTempBodyFiles()
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("temporary file is not removed: %v", err)
+	}
+}
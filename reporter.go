@@ -1,11 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"html/template"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"text/tabwriter"
@@ -25,7 +31,6 @@ type Reporter interface {
 
 // ConsoleReporter is a simple reporter that outputs everything to the StdOut.
 type ConsoleReporter struct {
-	ExitCode   int
 	LogHTTP    bool
 	Writer     io.Writer
 	IndentSize int
@@ -144,6 +149,14 @@ func (r *ConsoleReporter) Report(results []TestResult) {
 				r.StartLine()
 				r.Write(trace.RequestMethod).Write(" ").Write(trace.RequestURL).Write(" [").Write(trace.ExecFrame.Duration().Round(time.Millisecond)).Write("]")
 
+				if len(trace.Attempts) > 1 {
+					r.Write(" (").Write(len(trace.Attempts)).Write(" attempts)")
+
+					if r.LogHTTP {
+						r.WriteAttempts(trace.Attempts)
+					}
+				}
+
 				for exp, failed := range trace.ExpDesc {
 					r.Indent()
 					r.StartLine()
@@ -192,6 +205,23 @@ func (r *ConsoleReporter) Report(results []TestResult) {
 	r.ioMutex.Unlock()
 }
 
+// WriteAttempts outputs timing and result of every try to make a call
+func (r *ConsoleReporter) WriteAttempts(attempts []CallAttempt) {
+	r.Indent()
+	for _, a := range attempts {
+		r.StartLine()
+		line := fmt.Sprintf("#%d [%s]", a.Num, a.ExecFrame.Duration().Round(time.Millisecond))
+		if a.StatusCode != 0 {
+			line += fmt.Sprintf(" %d", a.StatusCode)
+		}
+		if a.ErrorCause != nil {
+			line += " " + strings.SplitN(a.ErrorCause.Error(), "\n", 2)[0]
+		}
+		r.WriteDimmed(line)
+	}
+	r.Unindent()
+}
+
 func (r ConsoleReporter) WriteDimmed(content interface{}) ConsoleReporter {
 	c := color.New(color.FgHiBlack)
 	c.Print(content)
@@ -266,7 +296,7 @@ func (r ConsoleReporter) Flush() {
 
 // NewConsoleReporter returns new instance of console reporter
 func NewConsoleReporter(logHTTP bool) Reporter {
-	return &ConsoleReporter{ExitCode: 0, ioMutex: &sync.Mutex{}, Writer: os.Stdout, LogHTTP: logHTTP}
+	return &ConsoleReporter{ioMutex: &sync.Mutex{}, Writer: os.Stdout, LogHTTP: logHTTP}
 }
 
 // JUnitXMLReporter produces separate xml file for each test sute
@@ -355,14 +385,18 @@ func (r *JUnitXMLReporter) Report(results []TestResult) {
 
 			errIndex := 0
 			errRespDump := ""
+			errAttempts := ""
 			for index, trace := range result.Traces {
 				if trace.hasError() {
 					errIndex = index
-					errRespDump = string(trace.ResponseDump)
+					errRespDump = maskCredentials(trace.ResponseDump)
+					if len(trace.Attempts) > 1 {
+						errAttempts = fmt.Sprintf(" (after %d attempts)", len(trace.Attempts))
+					}
 				}
 			}
 
-			errDetails := fmt.Sprintf("On Call #%d - %s\n\n%s", errIndex+1, errMsg, errRespDump)
+			errDetails := fmt.Sprintf("On Call #%d%s - %s\n\n%s", errIndex+1, errAttempts, errMsg, errRespDump)
 
 			testCase.Failure = &failure{
 				Type:    errType,
@@ -421,6 +455,328 @@ func NewJUnitReporter(outdir string) Reporter {
 	return &JUnitXMLReporter{OutPath: outdir}
 }
 
+// JSONReporter writes all results including call details to a single json file
+type JSONReporter struct {
+	// output file
+	OutPath string
+
+	execFrame TimeFrame
+	suites    []jsonSuite
+	mutex     sync.Mutex
+}
+
+type jsonReport struct {
+	Start      time.Time   `json:"start"`
+	End        time.Time   `json:"end"`
+	DurationMs int64       `json:"durationMs"`
+	Summary    jsonSummary `json:"summary"`
+	Suites     []jsonSuite `json:"suites"`
+}
+
+type jsonSummary struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+type jsonSuite struct {
+	Name     string     `json:"name"`
+	Package  string     `json:"package"`
+	FullName string     `json:"fullName"`
+	Cases    []jsonCase `json:"cases"`
+}
+
+type jsonCase struct {
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	SkippedMsg string     `json:"skippedMessage,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Start      time.Time  `json:"start"`
+	DurationMs int64      `json:"durationMs"`
+	Calls      []jsonCall `json:"calls"`
+}
+
+type jsonCall struct {
+	Index        int               `json:"index"`
+	Method       string            `json:"method,omitempty"`
+	URL          string            `json:"url,omitempty"`
+	StatusCode   int               `json:"statusCode,omitempty"`
+	DurationMs   int64             `json:"durationMs"`
+	Expectations []jsonExpectation `json:"expectations"`
+	Error        string            `json:"error,omitempty"`
+	Attempts     []jsonAttempt     `json:"attempts,omitempty"`
+	RequestDump  string            `json:"request,omitempty"`
+	ResponseDump string            `json:"response,omitempty"`
+}
+
+type jsonExpectation struct {
+	Description string `json:"description"`
+	Passed      bool   `json:"passed"`
+}
+
+type jsonAttempt struct {
+	Num        int    `json:"num"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (r *JSONReporter) Init() {
+	r.execFrame = TimeFrame{Start: time.Now()}
+}
+
+func (r *JSONReporter) Report(results []TestResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	s := jsonSuite{
+		Name:     results[0].Suite.Name,
+		Package:  results[0].Suite.PackageName(),
+		FullName: results[0].Suite.FullName(),
+		Cases:    make([]jsonCase, 0, len(results)),
+	}
+
+	for _, result := range results {
+		s.Cases = append(s.Cases, newJSONCase(result))
+	}
+
+	r.mutex.Lock()
+	r.suites = append(r.suites, s)
+	r.mutex.Unlock()
+}
+
+func newJSONCase(result TestResult) jsonCase {
+	c := jsonCase{
+		Name:       result.Case.Name,
+		Status:     statusPassed.Label,
+		Start:      result.ExecFrame.Start,
+		DurationMs: result.ExecFrame.Duration().Milliseconds(),
+		Calls:      make([]jsonCall, 0, len(result.Traces)),
+	}
+
+	if result.Skipped {
+		c.Status = statusSkipped.Label
+		c.SkippedMsg = result.SkippedMsg
+	} else if result.hasError() {
+		c.Status = statusFailed.Label
+		c.Error = result.Error()
+	}
+
+	for index, trace := range result.Traces {
+		call := jsonCall{
+			Index:        index,
+			Method:       trace.RequestMethod,
+			URL:          trace.RequestURL,
+			StatusCode:   trace.StatusCode,
+			DurationMs:   trace.ExecFrame.Duration().Milliseconds(),
+			Expectations: make([]jsonExpectation, 0, len(trace.ExpDesc)),
+			RequestDump:  maskCredentials(trace.RequestDump),
+			ResponseDump: maskCredentials(trace.ResponseDump),
+		}
+
+		if trace.hasError() {
+			call.Error = trace.ErrorCause.Error()
+		}
+
+		for desc, failed := range trace.ExpDesc {
+			call.Expectations = append(call.Expectations, jsonExpectation{Description: desc, Passed: !failed})
+		}
+		sort.Slice(call.Expectations, func(i, j int) bool {
+			return call.Expectations[i].Description < call.Expectations[j].Description
+		})
+
+		for _, a := range trace.Attempts {
+			attempt := jsonAttempt{Num: a.Num, StatusCode: a.StatusCode, DurationMs: a.ExecFrame.Duration().Milliseconds()}
+			if a.ErrorCause != nil {
+				attempt.Error = a.ErrorCause.Error()
+			}
+			call.Attempts = append(call.Attempts, attempt)
+		}
+
+		c.Calls = append(c.Calls, call)
+	}
+
+	return c
+}
+
+func (r *JSONReporter) Flush() {
+	data, err := json.MarshalIndent(r.collect(), "", "  ")
+	if err != nil {
+		panic(err)
+	}
+
+	writeReportFile(r.OutPath, data)
+}
+
+// collect builds report of all results received so far
+func (r *JSONReporter) collect() jsonReport {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.execFrame.End = time.Now()
+
+	report := jsonReport{
+		Start:      r.execFrame.Start,
+		End:        r.execFrame.End,
+		DurationMs: r.execFrame.Duration().Milliseconds(),
+		Suites:     r.suites,
+	}
+
+	if report.Suites == nil {
+		report.Suites = []jsonSuite{}
+	}
+
+	for _, s := range r.suites {
+		for _, c := range s.Cases {
+			report.Summary.Total++
+			switch c.Status {
+			case statusFailed.Label:
+				report.Summary.Failed++
+			case statusSkipped.Label:
+				report.Summary.Skipped++
+			default:
+				report.Summary.Passed++
+			}
+		}
+	}
+
+	return report
+}
+
+func writeReportFile(path string, data []byte) {
+	err := os.MkdirAll(filepath.Dir(path), 0777)
+	if err != nil {
+		panic(err)
+	}
+
+	err = ioutil.WriteFile(path, data, 0666)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// dumpHeader matches header line of request/response dump or header option of curl command
+var dumpHeader = regexp.MustCompile(`(?m)(^|-H ')([A-Za-z0-9-]+): ([^'\n]*)`)
+
+// maskCredentials hides values of credential headers in request/response dump, so report files could be shared
+func maskCredentials(dump string) string {
+	return dumpHeader.ReplaceAllStringFunc(dump, func(header string) string {
+		parts := dumpHeader.FindStringSubmatch(header)
+
+		masked, ok := credentialPlaceholder(parts[2], parts[3], func(name string) string { return "***" })
+		if !ok {
+			return header
+		}
+
+		return parts[1] + parts[2] + ": " + masked
+	})
+}
+
+// NewJSONReporter creates reporter that writes all results to the json file
+func NewJSONReporter(outPath string) Reporter {
+	return &JSONReporter{OutPath: outPath}
+}
+
+// HTMLReporter writes all results to a single static html page
+type HTMLReporter struct {
+	// output file
+	OutPath string
+
+	results JSONReporter
+}
+
+func (r *HTMLReporter) Init() {
+	r.results.Init()
+}
+
+func (r *HTMLReporter) Report(results []TestResult) {
+	r.results.Report(results)
+}
+
+func (r *HTMLReporter) Flush() {
+	var buf bytes.Buffer
+
+	err := htmlReportTemplate.Execute(&buf, r.results.collect())
+	if err != nil {
+		panic(err)
+	}
+
+	writeReportFile(r.OutPath, buf.Bytes())
+}
+
+// NewHTMLReporter creates reporter that writes all results to the html file
+func NewHTMLReporter(outPath string) Reporter {
+	return &HTMLReporter{OutPath: outPath}
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Bozr report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.summary span { margin-right: 1.5em; }
+.suite { margin-top: 2em; }
+.case { border-left: 4px solid #ccc; margin: 0.8em 0; padding: 0.3em 0.8em; background: #fafafa; }
+.case.passed { border-color: #2e7d32; }
+.case.failed { border-color: #c62828; }
+.case.skipped { border-color: #f9a825; }
+.status { font-weight: bold; font-size: 0.85em; }
+.passed .status, .exp.passed { color: #2e7d32; }
+.failed .status, .exp.failed { color: #c62828; }
+.skipped .status { color: #f9a825; }
+.call { margin: 0.5em 0 0.5em 1em; }
+.muted { color: #777; font-size: 0.85em; }
+pre { background: #fff; border: 1px solid #ddd; padding: 0.6em; overflow-x: auto; white-space: pre-wrap; }
+pre.error { border-color: #c62828; }
+summary { cursor: pointer; }
+ul { margin: 0.3em 0; }
+</style>
+</head>
+<body>
+<h1>Bozr report</h1>
+<p class="muted">{{.Start.Format "2006-01-02 15:04:05"}}, {{.DurationMs}} ms</p>
+<p class="summary">
+<span>Total: {{.Summary.Total}}</span>
+<span class="exp passed">Passed: {{.Summary.Passed}}</span>
+<span class="exp failed">Failed: {{.Summary.Failed}}</span>
+<span>Skipped: {{.Summary.Skipped}}</span>
+</p>
+{{range .Suites}}
+<div class="suite">
+<h2>{{.FullName}}</h2>
+{{range .Cases}}
+<div class="case {{lower .Status}}">
+<div><span class="status">{{.Status}}</span> {{.Name}} <span class="muted">{{.DurationMs}} ms</span></div>
+{{if .SkippedMsg}}<div class="muted">{{.SkippedMsg}}</div>{{end}}
+{{range .Calls}}
+<div class="call">
+<div>#{{.Index}} {{.Method}} {{.URL}} {{if .StatusCode}}<span class="muted">{{.StatusCode}}</span>{{end}} <span class="muted">{{.DurationMs}} ms</span></div>
+<ul>
+{{range .Expectations}}<li class="exp {{if .Passed}}passed{{else}}failed{{end}}">{{.Description}}</li>
+{{end}}</ul>
+{{if .Error}}<pre class="error">{{.Error}}</pre>{{end}}
+{{if .Attempts}}<details><summary>Attempts ({{len .Attempts}})</summary><ul>
+{{range .Attempts}}<li>#{{.Num}} {{if .StatusCode}}{{.StatusCode}} {{end}}{{.DurationMs}} ms {{.Error}}</li>
+{{end}}</ul></details>{{end}}
+{{if .RequestDump}}<details><summary>Request</summary><pre>{{.RequestDump}}</pre></details>{{end}}
+{{if .ResponseDump}}<details><summary>Response</summary><pre>{{.ResponseDump}}</pre></details>{{end}}
+</div>
+{{end}}
+</div>
+{{end}}
+</div>
+{{end}}
+</body>
+</html>
+`))
+
 // MultiReporter broadcasts events to another reporters.
 type MultiReporter struct {
 	Reporters []Reporter
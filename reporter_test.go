@@ -1,8 +1,11 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/fatih/color"
+	"io/ioutil"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -26,7 +29,7 @@ func TestConsoleReporterReport_ErrorAfterPassedExp_Reported(t *testing.T) {
 		expectedWriting: reportedError,
 	}
 
-	reporter := &ConsoleReporter{ExitCode: 0, Writer: &writer, ioMutex: &sync.Mutex{}, LogHTTP: false}
+	reporter := &ConsoleReporter{Writer: &writer, ioMutex: &sync.Mutex{}, LogHTTP: false}
 
 	color.Output = &writer // prevent stdout and invalid test result parsing in IDE (reacts on words 'FAILED')
 
@@ -67,3 +70,129 @@ func TestJUnitReporterEmptyResults(t *testing.T) {
 	// then
 	// no nil pointer panic
 }
+
+func TestJSONReporterWritesResults(t *testing.T) {
+	// given
+	outPath := filepath.Join(t.TempDir(), "report", "results.json")
+	reporter := NewJSONReporter(outPath)
+	reporter.Init()
+
+	failed := &CallTrace{
+		RequestMethod: "GET",
+		RequestURL:    "http://example.com/users/1",
+		StatusCode:    404,
+		ErrorCause:    errors.New("unexpected status code"),
+		ExpDesc:       map[string]bool{"Status code is 200": true},
+		RequestDump:   "GET /users/1 HTTP/1.1\nAuthorization: Bearer live-token",
+	}
+	passed := &CallTrace{
+		RequestMethod: "GET",
+		RequestURL:    "http://example.com/users",
+		StatusCode:    200,
+		ExpDesc:       map[string]bool{"Status code is 200": false},
+	}
+
+	suite := TestSuite{Name: "users", Dir: "examples"}
+
+	// when
+	reporter.Report([]TestResult{
+		{Suite: suite, Case: TestCase{Name: "list"}, Traces: []*CallTrace{passed}},
+		{Suite: suite, Case: TestCase{Name: "get"}, Traces: []*CallTrace{failed}},
+		{Suite: suite, Case: TestCase{Name: "delete"}, Skipped: true, SkippedMsg: "not implemented"},
+	})
+	reporter.Flush()
+
+	// then
+	content, err := ioutil.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := jsonReport{}
+	err = json.Unmarshal(content, &report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Summary != (jsonSummary{Total: 3, Passed: 1, Failed: 1, Skipped: 1}) {
+		t.Errorf("unexpected summary %+v", report.Summary)
+	}
+
+	if len(report.Suites) != 1 || report.Suites[0].FullName != "examples.users" {
+		t.Fatalf("unexpected suites %+v", report.Suites)
+	}
+
+	get := report.Suites[0].Cases[1]
+	if get.Status != "FAILED" || len(get.Calls) != 1 {
+		t.Fatalf("unexpected case %+v", get)
+	}
+
+	call := get.Calls[0]
+	if call.StatusCode != 404 || call.Error != "unexpected status code" || call.URL != failed.RequestURL {
+		t.Errorf("unexpected call %+v", call)
+	}
+
+	if len(call.Expectations) != 1 || call.Expectations[0].Passed {
+		t.Errorf("unexpected expectations %+v", call.Expectations)
+	}
+
+	if call.RequestDump != "GET /users/1 HTTP/1.1\nAuthorization: Bearer ***" {
+		t.Errorf("credentials are not masked %#v", call.RequestDump)
+	}
+}
+
+func TestHTMLReporterWritesResults(t *testing.T) {
+	// given
+	outPath := filepath.Join(t.TempDir(), "report.html")
+	reporter := NewHTMLReporter(outPath)
+	reporter.Init()
+
+	trace := &CallTrace{
+		RequestMethod: "POST",
+		RequestURL:    "http://example.com/users",
+		StatusCode:    400,
+		ErrorCause:    errors.New("the body does not match expectations: \n\tname: <script>"),
+		ExpDesc:       map[string]bool{"Status code is 201": true},
+		RequestDump:   "POST /users HTTP/1.1\nAuthorization: Bearer live-token\nCookie: session=live\nSignature: live-signature",
+	}
+
+	// when
+	reporter.Report([]TestResult{
+		{Suite: TestSuite{Name: "users"}, Case: TestCase{Name: "create"}, Traces: []*CallTrace{trace}},
+	})
+	reporter.Flush()
+
+	// then
+	content, err := ioutil.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := string(content)
+	for _, expected := range []string{"create", "Status code is 201", "POST /users HTTP/1.1", "Authorization: Bearer ***", "name: &lt;script&gt;", "Failed: 1"} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("report does not contain %#v", expected)
+		}
+	}
+
+	if strings.Contains(page, "live") {
+		t.Errorf("report contains credentials %s", page)
+	}
+}
+
+func TestMaskCredentials(t *testing.T) {
+	tests := []struct {
+		dump string
+		want string
+	}{
+		{dump: "GET / HTTP/1.1\nAuthorization: Basic am9objpzZWNyZXQ=\nX-Request-Id: r-1", want: "GET / HTTP/1.1\nAuthorization: Basic ***\nX-Request-Id: r-1"},
+		{dump: "\nSet-Cookie: session=live; HttpOnly\nX-Api-Key: k\n\n{\"token\": \"t\"}", want: "\nSet-Cookie: ***\nX-Api-Key: ***\n\n{\"token\": \"t\"}"},
+		{dump: "curl -X 'GET' -H 'X-Amz-Security-Token: live' -H 'Accept: */*' 'http://example.com'", want: "curl -X 'GET' -H 'X-Amz-Security-Token: ***' -H 'Accept: */*' 'http://example.com'"},
+	}
+
+	for _, tt := range tests {
+		if got := maskCredentials(tt.dump); got != tt.want {
+			t.Errorf("expected %#v, got %#v", tt.want, got)
+		}
+	}
+}
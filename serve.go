@@ -0,0 +1,335 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// placeholderRegexp finds both variables, e.g. {id}, and template expressions, e.g. {{ .Now }}
+var placeholderRegexp = regexp.MustCompile(`\{\{.*?\}\}|\{[^{}]+\}`)
+
+// Stub is a response served by mock server for the matching request.
+// Request and response are described by On and Expect sections of a call.
+type Stub struct {
+	// suite and test case the stub is built from
+	Source string
+
+	Method string
+	// path template, e.g. /users/{id}
+	URL     string
+	Path    *regexp.Regexp
+	Params  map[string]*regexp.Regexp
+	Headers map[string]*regexp.Regexp
+
+	StatusCode      int
+	ResponseHeaders map[string]string
+	Body            []byte
+}
+
+// NewStub builds stub from the call, test case args are applied to request and response templates.
+func NewStub(source string, call Call, vars *Vars) (*Stub, error) {
+	on := call.On
+
+	rawURL := vars.ApplyTo(on.URL)
+	rawPath, rawQuery := rawURL, ""
+	if i := strings.Index(rawURL, "?"); i >= 0 {
+		rawPath, rawQuery = rawURL[:i], rawURL[i+1:]
+	}
+
+	if i := strings.Index(rawPath, "://"); i >= 0 {
+		rawPath = rawPath[i+3:]
+		if j := strings.Index(rawPath, "/"); j >= 0 {
+			rawPath = rawPath[j:]
+		} else {
+			rawPath = "/"
+		}
+	} else if !strings.HasPrefix(rawPath, "/") {
+		segment, rest := rawPath, ""
+		if j := strings.Index(rawPath, "/"); j >= 0 {
+			segment, rest = rawPath[:j], rawPath[j:]
+		}
+
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			// base url placeholder, e.g. {{.BaseURL}}/users
+			rawPath = "/" + strings.TrimPrefix(rest, "/")
+		} else {
+			// relative url, e.g. users/{id}
+			rawPath = "/" + rawPath
+		}
+	}
+
+	path, err := stubPattern(rawPath, "[^/]+")
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %s: %s", on.URL, err)
+	}
+
+	stub := &Stub{
+		Source:          source,
+		Method:          strings.ToUpper(on.Method),
+		URL:             rawPath,
+		Path:            path,
+		Params:          make(map[string]*regexp.Regexp),
+		Headers:         make(map[string]*regexp.Regexp),
+		StatusCode:      http.StatusOK,
+		ResponseHeaders: make(map[string]string),
+	}
+
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %s: %s", on.URL, err)
+	}
+
+	for key, values := range params {
+		stub.Params[key], err = stubPattern(values[0], ".*")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key, value := range on.Params {
+		stub.Params[key], err = stubPattern(vars.ApplyTo(value), ".*")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key, value := range on.Headers {
+		stub.Headers[http.CanonicalHeaderKey(key)], err = stubPattern(vars.ApplyTo(value), ".*")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	expect := call.Expect
+	if expect.StatusCode != nil {
+		stub.StatusCode = *expect.StatusCode
+	}
+
+	for key, value := range expect.Headers {
+		stub.ResponseHeaders[key] = vars.ApplyTo(value)
+	}
+
+	if expect.ContentType != "" {
+		stub.ResponseHeaders["Content-Type"] = expect.ContentType
+	}
+
+	body := expect.ExactBody
+	if body == nil {
+		body = expect.Body
+	}
+
+	body, _ = stubValue(body)
+
+	switch typed := body.(type) {
+	case nil:
+	case string:
+		stub.Body = []byte(vars.ApplyTo(typed))
+	default:
+		content, err := json.Marshal(typed)
+		if err != nil {
+			return nil, err
+		}
+		stub.Body = []byte(vars.ApplyTo(string(content)))
+
+		if _, ok := stub.ResponseHeaders["Content-Type"]; !ok {
+			stub.ResponseHeaders["Content-Type"] = "application/json"
+		}
+	}
+
+	return stub, nil
+}
+
+// stubValue removes values expected by operators (e.g. {"$gt": 0}) from the body, as there is no exact value
+// to answer with. Values of $literal are unwrapped. Returns false if the value itself is removed.
+func stubValue(value interface{}) (interface{}, bool) {
+	if ops, ok := asOperator(value); ok {
+		literal, ok := ops["$literal"]
+		return literal, ok && len(ops) == 1
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			if v, ok := stubValue(item); ok {
+				result[key] = v
+			}
+		}
+		return result, true
+
+	case []interface{}:
+		result := make([]interface{}, 0, len(typed))
+		for _, item := range typed {
+			if v, ok := stubValue(item); ok {
+				result = append(result, v)
+			}
+		}
+		return result, true
+	}
+
+	return value, true
+}
+
+// stubPattern converts template to regular expression where placeholders match any value
+func stubPattern(tmpl string, wildcard string) (*regexp.Regexp, error) {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+
+	last := 0
+	for _, loc := range placeholderRegexp.FindAllStringIndex(tmpl, -1) {
+		pattern.WriteString(regexp.QuoteMeta(tmpl[last:loc[0]]))
+		pattern.WriteString(wildcard)
+		last = loc[1]
+	}
+
+	pattern.WriteString(regexp.QuoteMeta(tmpl[last:]))
+	pattern.WriteString("$")
+
+	return regexp.Compile(pattern.String())
+}
+
+// Matches checks request corresponds to the stub
+func (s *Stub) Matches(req *http.Request) bool {
+	if s.Method != req.Method || !s.Path.MatchString(req.URL.Path) {
+		return false
+	}
+
+	query := req.URL.Query()
+	for key, pattern := range s.Params {
+		if _, ok := query[key]; !ok || !pattern.MatchString(query.Get(key)) {
+			return false
+		}
+	}
+
+	for key, pattern := range s.Headers {
+		if _, ok := req.Header[key]; !ok || !pattern.MatchString(req.Header.Get(key)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// StubServer answers requests with the first matching stub
+type StubServer struct {
+	Stubs []*Stub
+}
+
+// NewStubServer builds stubs from all calls of the suites
+func NewStubServer(suites <-chan TestSuite) (*StubServer, error) {
+	server := &StubServer{}
+
+	for suite := range suites {
+		for _, testCase := range suite.Cases {
+			if testCase.Ignore != nil {
+				continue
+			}
+
+			vars := NewVars("")
+			err := vars.AddAll(testCase.Args)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", suite.FullName(), err)
+			}
+
+			source := suite.FullName() + "/" + testCase.Name
+
+			calls := append(append(append([]Call{}, suite.BeforeAll...), suite.BeforeEach...), testCase.Calls...)
+			calls = append(append(calls, suite.AfterEach...), suite.AfterAll...)
+
+			for i, call := range calls {
+				stub, err := NewStub(source, call, vars)
+				if err != nil {
+					return nil, fmt.Errorf("%s, call #%d: %s", source, i, err)
+				}
+				server.Stubs = append(server.Stubs, stub)
+			}
+		}
+	}
+
+	return server, nil
+}
+
+func (s *StubServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	for _, stub := range s.Stubs {
+		if !stub.Matches(req) {
+			continue
+		}
+
+		debugf("%s %s matches %s\n", req.Method, req.URL, stub.Source)
+
+		for key, value := range stub.ResponseHeaders {
+			w.Header().Set(key, value)
+		}
+		w.WriteHeader(stub.StatusCode)
+		w.Write(stub.Body)
+		return
+	}
+
+	debugf("%s %s matches no stub\n", req.Method, req.URL)
+	http.Error(w, fmt.Sprintf("No stub matches %s %s", req.Method, req.URL), http.StatusNotFound)
+}
+
+// serveCommand starts mock server which serves suites as stubs
+func serveCommand(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "Address to listen on")
+	flags.BoolVar(&debugFlag, "d", false, "Enable debug mode")
+	flags.BoolVar(&debugFlag, "debug", false, "Enable debug mode")
+	flags.Usage = func() {
+		h := "Usage:\n"
+		h += "  bozr serve [OPTIONS] (DIR|FILE)\n\n"
+
+		h += "Options:\n"
+		h += "      --addr    Address to listen on. Default is :8080\n"
+		h += "  -d, --debug   Enable debug mode\n"
+
+		fmt.Fprint(os.Stderr, h)
+	}
+
+	flags.Parse(args)
+
+	initLogger()
+
+	dir := flags.Arg(0)
+	if dir == "" {
+		flags.Usage()
+		fmt.Println()
+		terminate(exitCodeRuntimeError, "You must specify a directory or file with tests.")
+		return
+	}
+
+	_, err := os.Lstat(dir)
+	if err != nil {
+		terminate(exitCodeRuntimeError, err.Error())
+		return
+	}
+
+	err = ValidateSuites(dir, suiteExts, ignoredSuiteExts)
+	if err != nil {
+		terminate(exitCodeInvalidSuites, "One or more test suites are invalid.", err.Error())
+		return
+	}
+
+	server, err := NewStubServer(NewSuiteLoader(dir, suiteExts, ignoredSuiteExts, nil))
+	if err != nil {
+		terminate(exitCodeRuntimeError, err.Error())
+		return
+	}
+
+	for _, stub := range server.Stubs {
+		fmt.Printf("%-7s %s (%s)\n", stub.Method, stub.URL, stub.Source)
+	}
+
+	fmt.Printf("\nServing %d stubs on %s\n", len(server.Stubs), *addr)
+
+	err = http.ListenAndServe(*addr, server)
+	if err != nil {
+		terminate(exitCodeRuntimeError, err.Error())
+	}
+}
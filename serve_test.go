@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStubServer(t *testing.T) {
+	// given
+	initLogger()
+
+	created := 201
+	ok := 200
+
+	suites := make(chan TestSuite, 1)
+	suites <- TestSuite{Name: "users", Cases: []TestCase{
+		{
+			Name: "create",
+			Args: map[string]any{"name": "John"},
+			Calls: []Call{
+				{
+					On:     On{Method: "POST", URL: "{ctx:base_url}/users", Headers: map[string]string{"Authorization": "Bearer {token}"}},
+					Expect: Expect{StatusCode: &created, Headers: map[string]string{"Location": "/users/1"}, Body: map[string]interface{}{"name": "{name}"}},
+				},
+				{
+					On:     On{Method: "GET", URL: "/users/{id}", Params: map[string]string{"fields": "name"}},
+					Expect: Expect{StatusCode: &ok, ContentType: "text/plain", ExactBody: "John"},
+				},
+				{
+					On: On{Method: "GET", URL: "orders/{id}"},
+					Expect: Expect{StatusCode: &ok, Body: map[string]interface{}{
+						"id":     map[string]interface{}{"$gt": 0},
+						"status": "new",
+						"meta":   map[string]interface{}{"$literal": map[string]interface{}{"$type": "order"}},
+					}},
+				},
+			},
+		},
+	}}
+	close(suites)
+
+	stubs, err := NewStubServer(suites)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(stubs)
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		headers    map[string]string
+		statusCode int
+		body       string
+	}{
+		{name: "post matched", method: "POST", path: "/users", headers: map[string]string{"Authorization": "Bearer abc"}, statusCode: 201, body: `{"name":"John"}`},
+		{name: "required header missing", method: "POST", path: "/users", statusCode: 404},
+		{name: "path placeholder matched", method: "GET", path: "/users/42?fields=name", statusCode: 200, body: "John"},
+		{name: "param missing", method: "GET", path: "/users/42", statusCode: 404},
+		{name: "placeholder doesn't match several segments", method: "GET", path: "/users/42/orders?fields=name", statusCode: 404},
+		{name: "relative url without placeholder", method: "GET", path: "/orders/7", statusCode: 200, body: `{"meta":{"$type":"order"},"status":"new"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			// then
+			if resp.StatusCode != tt.statusCode {
+				t.Fatalf("unexpected status code %d", resp.StatusCode)
+			}
+
+			body, _ := ioutil.ReadAll(resp.Body)
+			if tt.body != "" && string(body) != tt.body {
+				t.Errorf("unexpected body %s", body)
+			}
+		})
+	}
+}
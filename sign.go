@@ -0,0 +1,289 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Signing describes how to sign the request. Only one of the schemes is expected.
+type Signing struct {
+	HMAC  *HMACSigning  `json:"hmac,omitempty"`
+	AWSV4 *AWSV4Signing `json:"awsSigV4,omitempty"`
+}
+
+// HMACSigning is a generic scheme: signature of the canonical string is put into the header
+type HMACSigning struct {
+	// sha1, sha256 (default) or sha512
+	Algorithm string `json:"algorithm,omitempty"`
+	Secret    string `json:"secret"`
+	// string to sign with request tokens, e.g. "{req:method}\n{req:path}\n{req:bodySha256}"
+	Canonical string `json:"canonical"`
+	// Signature by default
+	Header string `json:"header,omitempty"`
+	// header value with {req:signature} token, signature only by default
+	Value string `json:"value,omitempty"`
+	// base64 (default) or hex
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// AWSV4Signing is AWS Signature Version 4 (Authorization header)
+type AWSV4Signing struct {
+	AccessKey    string `json:"accessKey"`
+	SecretKey    string `json:"secretKey"`
+	SessionToken string `json:"sessionToken,omitempty"`
+	Region       string `json:"region"`
+	Service      string `json:"service"`
+}
+
+var requestTokenRegexp = regexp.MustCompile(`\{req:([a-zA-Z0-9]+)(?::([^}]+))?\}`)
+
+// sign adds signature headers, placeholders are applied to the settings before request tokens
+func (s *Signing) sign(req *http.Request, body []byte, tmplCtx *TemplateContext) error {
+	var err error
+
+	switch {
+	case s.HMAC != nil:
+		err = s.HMAC.sign(req, body, tmplCtx)
+
+	case s.AWSV4 != nil:
+		o := *s.AWSV4
+		for _, field := range []*string{&o.AccessKey, &o.SecretKey, &o.SessionToken, &o.Region, &o.Service} {
+			*field = tmplCtx.ApplyTo(*field)
+		}
+
+		if !tmplCtx.HasErrors() {
+			signAWSV4(req, body, o, time.Now())
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("cannot sign request: %s", err)
+	}
+
+	if tmplCtx.HasErrors() {
+		return tmplCtx.Error()
+	}
+
+	return nil
+}
+
+func (h *HMACSigning) sign(req *http.Request, body []byte, tmplCtx *TemplateContext) error {
+	newHash, ok := map[string]func() hash.Hash{"": sha256.New, "sha256": sha256.New, "sha1": sha1.New, "sha512": sha512.New}[strings.ToLower(h.Algorithm)]
+	if !ok {
+		return fmt.Errorf("unsupported algorithm %s", h.Algorithm)
+	}
+
+	canonical, err := applyRequestTokens(tmplCtx.ApplyTo(h.Canonical), req, body)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(newHash, []byte(tmplCtx.ApplyTo(h.Secret)))
+	mac.Write([]byte(canonical))
+
+	var signature string
+	switch strings.ToLower(h.Encoding) {
+	case "", "base64":
+		signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	case "hex":
+		signature = hex.EncodeToString(mac.Sum(nil))
+	default:
+		return fmt.Errorf("unsupported encoding %s", h.Encoding)
+	}
+
+	debugf("HMAC signature of %#v: %s", canonical, signature)
+
+	header := h.Header
+	if header == "" {
+		header = "Signature"
+	}
+
+	value := tmplCtx.ApplyTo(h.Value)
+	if value == "" {
+		value = signature
+	} else {
+		value = strings.Replace(value, "{req:signature}", signature, -1)
+	}
+
+	req.Header.Set(header, value)
+
+	return nil
+}
+
+// applyRequestTokens replaces tokens like {req:method} or {req:header:Date} with request data
+func applyRequestTokens(tmpl string, req *http.Request, body []byte) (string, error) {
+	var err error
+
+	result := requestTokenRegexp.ReplaceAllStringFunc(tmpl, func(token string) string {
+		parts := requestTokenRegexp.FindStringSubmatch(token)
+
+		switch parts[1] {
+		case "method":
+			return req.Method
+		case "path":
+			return req.URL.EscapedPath()
+		case "query":
+			return req.URL.Query().Encode() // sorted by key
+		case "host":
+			return req.URL.Host
+		case "url":
+			return req.URL.String()
+		case "body":
+			return string(body)
+		case "bodySha256":
+			sum := sha256.Sum256(body)
+			return hex.EncodeToString(sum[:])
+		case "bodyMd5":
+			sum := md5.Sum(body)
+			return base64.StdEncoding.EncodeToString(sum[:])
+		case "header":
+			return req.Header.Get(parts[2])
+		case "signature":
+			return token // value of the header only
+		}
+
+		err = fmt.Errorf("unknown request token %s", token)
+		return token
+	})
+
+	return result, err
+}
+
+// signAWSV4 sets Authorization header according to AWS Signature Version 4
+func signAWSV4(req *http.Request, body []byte, o AWSV4Signing, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	bodySum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(bodySum[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if o.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", o.SessionToken)
+	}
+	if o.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	req.Header.Del("Authorization")
+
+	headers := map[string]string{"host": req.URL.Host}
+	if req.Host != "" {
+		headers["host"] = req.Host
+	}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		awsCanonicalPath(req.URL.Path, o.Service != "s3"),
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, o.Region, o.Service, "aws4_request"}, "/")
+
+	requestSum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestSum[:])}, "\n")
+
+	key := []byte("AWS4" + o.SecretKey)
+	for _, part := range []string{date, o.Region, o.Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	debugf("AWS canonical request:\n%s", canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", o.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsEscape encodes everything except unreserved characters
+func awsEscape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+// awsCanonicalPath encodes path segments, twice for all services except S3
+func awsCanonicalPath(path string, twice bool) string {
+	if path == "" {
+		return "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = awsEscape(segment)
+		if twice {
+			segments[i] = awsEscape(segments[i])
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// awsCanonicalQuery sorts encoded parameters by name, then by value
+func awsCanonicalQuery(query url.Values) string {
+	pairs := [][2]string{}
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{awsEscape(key), awsEscape(value)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	params := make([]string, len(pairs))
+	for i, pair := range pairs {
+		params[i] = pair[0] + "=" + pair[1]
+	}
+
+	return strings.Join(params, "&")
+}
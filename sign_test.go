@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACSigning(t *testing.T) {
+	initLogger()
+
+	vars := NewVars("")
+	vars.Add("secret", "key")
+	vars.Add("keyId", "partner-1")
+
+	req, _ := http.NewRequest("POST", "http://example.com/api/orders?b=2&a=1", nil)
+	req.Header.Set("Date", "Tue, 01 Sep 2020 10:00:00 GMT")
+
+	sign := Signing{HMAC: &HMACSigning{
+		Secret:    "{secret}",
+		Canonical: "{req:method}\n{req:path}\n{req:query}\n{req:header:Date}\n{req:body}",
+		Header:    "Authorization",
+		Value:     "HMAC {keyId}:{req:signature}",
+		Encoding:  "hex",
+	}}
+
+	err := sign.sign(req, []byte(`{"id":1}`), NewTemplateContext(vars))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// echo -ne 'POST\n/api/orders\na=1&b=2\nTue, 01 Sep 2020 10:00:00 GMT\n{"id":1}' | openssl dgst -sha256 -hmac key
+	expected := "HMAC partner-1:ab4af14dfb94b4a92db0cae7c6f18fdf18e916d3aaae635fb78d9e2bbe662917"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("unexpected signature header. Expected: %s, Actual: %s", expected, got)
+	}
+}
+
+func TestHMACSigningUnknownToken(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+
+	sign := Signing{HMAC: &HMACSigning{Secret: "key", Canonical: "{req:unknown}"}}
+
+	err := sign.sign(req, nil, NewTemplateContext(NewVars("")))
+	if err == nil || !strings.Contains(err.Error(), "unknown request token {req:unknown}") {
+		t.Errorf("expected error of unknown token, got %v", err)
+	}
+}
+
+func TestSignAWSV4(t *testing.T) {
+	initLogger()
+
+	// get-vanilla case of AWS Signature Version 4 test suite
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+
+	signAWSV4(req, nil, AWSV4Signing{
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:    "us-east-1",
+		Service:   "service",
+	}, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("unexpected Authorization header.\nExpected: %s\nActual:   %s", expected, got)
+	}
+}
+
+func TestAWSCanonicalQuery(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://example.com/?b=2&a=x%20y&a=1", nil)
+
+	if got := awsCanonicalQuery(req.URL.Query()); got != "a=1&a=x%20y&b=2" {
+		t.Errorf("unexpected canonical query %s", got)
+	}
+
+	// key is a prefix of another one, "-" sorts before "="
+	req, _ = http.NewRequest("GET", "https://example.com/?a-b=2&a=1", nil)
+
+	if got := awsCanonicalQuery(req.URL.Query()); got != "a=1&a-b=2" {
+		t.Errorf("unexpected canonical query %s", got)
+	}
+
+	if got := awsCanonicalPath("/documents and settings/", true); got != "/documents%2520and%2520settings/" {
+		t.Errorf("unexpected canonical path %s", got)
+	}
+}
@@ -80,6 +80,22 @@ func (ctx *Funcs) Now(tz ...string) time.Time {
 	return time.Now().In(loc)
 }
 
+// JWTHS256 returns JWT signed with the secret.
+// Expiration is relative to now (e.g. "15m", "-1h" for expired token), claims are name and value pairs.
+func (ctx *Funcs) JWTHS256(secret, expiresIn string, claims ...interface{}) (string, error) {
+	return signJWT("HS256", []byte(secret), expiresIn, claims)
+}
+
+// JWTRS256 returns JWT signed with RSA private key from PEM file (path relative to the test suite file).
+func (ctx *Funcs) JWTRS256(keyFile, expiresIn string, claims ...interface{}) (string, error) {
+	key, err := readJWTKey(ctx.vars.suitePath, keyFile)
+	if err != nil {
+		return "", err
+	}
+
+	return signJWT("RS256", key, expiresIn, claims)
+}
+
 // TemplateContext backs and executes template
 type TemplateContext struct {
 	funcs  *Funcs
@@ -2,15 +2,21 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math"
 	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/http/cookiejar"
+	"net/textproto"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"text/template"
@@ -29,6 +35,17 @@ type TestSuite struct {
 	Dir string
 	// test cases listed in a file
 	Cases []TestCase
+
+	// calls executed once before and after all test cases
+	BeforeAll []Call
+	AfterAll  []Call
+
+	// calls executed before and after every test case
+	BeforeEach []Call
+	AfterEach  []Call
+
+	// default latency budget of every call in the suite
+	MaxDuration string
 }
 
 // PackageName builds name of a package based on folder where test is located
@@ -56,6 +73,36 @@ type TestCase struct {
 	Ignore *string        `json:"ignore,omitempty"`
 	Args   map[string]any `json:"args,omitempty"`
 	Calls  []Call         `json:"calls,omitempty"`
+	// default latency budget of every call in the test case
+	MaxDuration string `json:"maxDuration,omitempty"`
+	// labels to select test cases to run, e.g. smoke
+	Tags []string `json:"tags,omitempty"`
+	// cookies set by responses are sent by subsequent calls, enabled if not specified
+	CookieJar *bool `json:"cookieJar,omitempty"`
+	// overrides auth of environment profile
+	Auth *Auth `json:"auth,omitempty"`
+	// overrides signing of environment profile
+	Sign *Signing `json:"sign,omitempty"`
+	// time limit of every request in the test case, overrides --timeout, e.g. "5s"
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// withMaxDuration returns copy of calls where default latency budget is applied
+// to the calls without their own one
+func withMaxDuration(calls []Call, maxDuration string) []Call {
+	if maxDuration == "" {
+		return calls
+	}
+
+	result := make([]Call, len(calls))
+	for i, c := range calls {
+		if c.Expect.MaxDuration == "" {
+			c.Expect.MaxDuration = maxDuration
+		}
+		result[i] = c
+	}
+
+	return result
 }
 
 // Call defines metadata for one request-response verification within TestCase
@@ -64,22 +111,114 @@ type Call struct {
 	On       On                     `json:"on,omitempty"`
 	Expect   Expect                 `json:"expect,omitempty"`
 	Remember Remember               `json:"remember,omitempty"`
+	Retry    *Retry                 `json:"retry,omitempty"`
+	// overrides auth of the test case and environment profile
+	Auth *Auth `json:"auth,omitempty"`
+	// overrides signing of the test case and environment profile
+	Sign *Signing `json:"sign,omitempty"`
+	// time limit of the request, overrides timeout of the test case, e.g. "30s"
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Retry defines policy to re-send the request until expectations are met.
+// Useful for eventually-consistent endpoints.
+type Retry struct {
+	// total number of attempts including the first one
+	Attempts int `json:"attempts"`
+	// pause between attempts, e.g. "500ms", "2s"
+	Interval string `json:"interval,omitempty"`
+	// multiplier applied to the interval after each attempt
+	Backoff float64 `json:"backoff,omitempty"`
+	// status codes which stop retrying immediately
+	StopOnStatus []int `json:"stopOnStatus,omitempty"`
+}
+
+// again decides whether failed attempt should be repeated
+func (r *Retry) again(attempt int, trace *CallTrace, resp *Response) bool {
+	if r == nil || !trace.hasError() || attempt >= r.Attempts {
+		return false
+	}
+
+	if trace.RequestURL == "" {
+		return false
+	} // request was not even built, another attempt will fail the same way
+
+	if resp != nil {
+		for _, code := range r.StopOnStatus {
+			if resp.http.StatusCode == code {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// delay returns pause before the next attempt
+func (r *Retry) delay(attempt int) (time.Duration, error) {
+	if r.Interval == "" {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(r.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid retry interval: %s", r.Interval)
+	}
+
+	if r.Backoff > 0 {
+		interval = time.Duration(float64(interval) * math.Pow(r.Backoff, float64(attempt-1)))
+	}
+
+	return interval, nil
 }
 
 // Remember defines items from HTTP response to persist for usage in future calls
 type Remember struct {
 	BPath   map[string]string `json:"bodyPath,omitempty"`
 	Headers map[string]string `json:"headers,omitempty"`
+	// variable name to cookie name set by the response
+	Cookies map[string]string `json:"cookies,omitempty"`
+	// response body saved as is
+	BodyFile *RememberBodyFile `json:"bodyFile,omitempty"`
+}
+
+// RememberBodyFile saves response body to a file and remembers the file path
+type RememberBodyFile struct {
+	// path relative to the test suite file, temporary file is created if empty
+	Path string `json:"path,omitempty"`
+	// variable name to remember absolute path of the file
+	Var string `json:"var"`
 }
 
 // On is a metadata for building a HTTP request
 type On struct {
-	Method   string            `json:"method"`
-	URL      string            `json:"url"`
-	Headers  map[string]string `json:"headers"`
-	Params   map[string]string `json:"params"`
-	Body     json.RawMessage   `json:"body"`
-	BodyFile string            `json:"bodyFile"`
+	Method   string            `json:"method,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Params   map[string]string `json:"params,omitempty"`
+	Body     json.RawMessage   `json:"body,omitempty"`
+	BodyFile string            `json:"bodyFile,omitempty"`
+	// url-encoded form fields
+	Form      map[string]string `json:"form,omitempty"`
+	Multipart *Multipart        `json:"multipart,omitempty"`
+}
+
+// Multipart is a multipart/form-data request body
+type Multipart struct {
+	Fields map[string]string `json:"fields,omitempty"`
+	Files  []MultipartFile   `json:"files,omitempty"`
+}
+
+// MultipartFile is a file part of multipart request body
+type MultipartFile struct {
+	// form field name
+	Name string `json:"name"`
+	// path relative to the suite file
+	File string `json:"file"`
+	// defaults to the base name of the file
+	FileName string `json:"filename,omitempty"`
+	// defaults to the type by file extension
+	ContentType string `json:"contentType,omitempty"`
 }
 
 // BodyContent returns request body content regardless of its source
@@ -109,19 +248,166 @@ func (on On) BodyContent(suitePath string) (string, error) {
 	return string(dat), nil
 }
 
+// FormContent returns url-encoded or multipart body with its content type (with boundary).
+// Content type is empty if neither form nor multipart is specified.
+func (on On) FormContent(suitePath string, tmplCtx *TemplateContext) (string, string, error) {
+	if on.Form == nil && on.Multipart == nil {
+		return "", "", nil
+	}
+
+	if (on.Form != nil && on.Multipart != nil) || len(on.Body) > 0 || on.BodyFile != "" {
+		return "", "", errors.New("only one of body, bodyFile, form and multipart can be specified")
+	}
+
+	if on.Form != nil {
+		form := url.Values{}
+		for key, valueTmpl := range on.Form {
+			form.Set(key, tmplCtx.ApplyTo(valueTmpl))
+		}
+
+		if tmplCtx.HasErrors() {
+			return "", "", tmplCtx.Error()
+		}
+
+		return form.Encode(), "application/x-www-form-urlencoded", nil
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	keys := make([]string, 0, len(on.Multipart.Fields))
+	for key := range on.Multipart.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err := writer.WriteField(key, tmplCtx.ApplyTo(on.Multipart.Fields[key]))
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	for _, part := range on.Multipart.Files {
+		err := writeFilePart(writer, suitePath, part, tmplCtx)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	if tmplCtx.HasErrors() {
+		return "", "", tmplCtx.Error()
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return "", "", err
+	}
+
+	return buf.String(), writer.FormDataContentType(), nil
+}
+
+// writeFilePart writes file as is, placeholders are applied only to its path and name
+func writeFilePart(writer *multipart.Writer, suitePath string, part MultipartFile, tmplCtx *TemplateContext) error {
+	uri, err := toAbsPath(suitePath, tmplCtx.ApplyTo(part.File))
+	if err != nil {
+		return err
+	}
+
+	content, err := ioutil.ReadFile(uri)
+	if err != nil {
+		return fmt.Errorf("can't read multipart file: %s", err.Error())
+	}
+
+	fileName := tmplCtx.ApplyTo(part.FileName)
+	if fileName == "" {
+		fileName = filepath.Base(uri)
+	}
+
+	contentType := part.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(uri))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": part.Name, "filename": fileName}))
+	header.Set("Content-Type", contentType)
+
+	w, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(content)
+	return err
+}
+
 // Expect is a metadata for HTTP response verification
 type Expect struct {
-	StatusCode *int `json:"statusCode"`
+	StatusCode *int `json:"statusCode,omitempty"`
 	// shortcut for content-type header
-	ContentType    string                 `json:"contentType"`
-	Headers        map[string]string      `json:"headers"`
-	BPath          map[string]interface{} `json:"bodyPath"`
-	Body           interface{}            `json:"body"`
-	ExactBody      interface{}            `json:"exactBody"`
-	Absent         []string               `json:"absent"`
-	BodySchemaRaw  json.RawMessage        `json:"bodySchema"`
-	BodySchemaFile string                 `json:"bodySchemaFile"`
-	BodySchemaURI  string                 `json:"bodySchemaURI"`
+	ContentType    string                 `json:"contentType,omitempty"`
+	Headers        map[string]string      `json:"headers,omitempty"`
+	BPath          map[string]interface{} `json:"bodyPath,omitempty"`
+	Body           interface{}            `json:"body,omitempty"`
+	ExactBody      interface{}            `json:"exactBody,omitempty"`
+	Absent         []string               `json:"absent,omitempty"`
+	BodySchemaRaw  json.RawMessage        `json:"bodySchema,omitempty"`
+	BodySchemaFile string                 `json:"bodySchemaFile,omitempty"`
+	BodySchemaURI  string                 `json:"bodySchemaURI,omitempty"`
+	// latency budget, e.g. "300ms"
+	MaxDuration string `json:"maxDuration,omitempty"`
+	// response is documented by the operation of OpenAPI spec
+	OpenAPI *OpenAPIExpect `json:"openapi,omitempty"`
+	// raw body assertions for binary content, e.g. images, pdf, archives
+	BodySize   *BodySize `json:"bodySize,omitempty"`
+	BodySha256 string    `json:"bodySha256,omitempty"`
+	// path relative to the suite file
+	BodyEqualsFile string `json:"bodyEqualsFile,omitempty"`
+	// media type detected by the body content (magic number), e.g. application/pdf
+	BodyType string `json:"bodyType,omitempty"`
+	// cookies set by the response by name
+	Cookies map[string]CookieExpect `json:"cookies,omitempty"`
+	// negotiated TLS connection of the response
+	TLS *TLSExpect `json:"tls,omitempty"`
+}
+
+// TLSExpect describes negotiated TLS connection, not specified properties are not checked
+type TLSExpect struct {
+	// exact protocol version, e.g. 1.3
+	Version string `json:"version,omitempty"`
+	// lowest acceptable protocol version, e.g. 1.2
+	MinVersion string `json:"minVersion,omitempty"`
+	// server certificate should not expire within the duration, e.g. "720h"
+	CertValidFor string `json:"certValidFor,omitempty"`
+}
+
+// CookieExpect is a value and attributes of the cookie set by response, not specified ones are not checked
+type CookieExpect struct {
+	Value    *string `json:"value,omitempty"`
+	Path     string  `json:"path,omitempty"`
+	Domain   string  `json:"domain,omitempty"`
+	Secure   *bool   `json:"secure,omitempty"`
+	HTTPOnly *bool   `json:"httpOnly,omitempty"`
+	// Lax, Strict or None
+	SameSite string `json:"sameSite,omitempty"`
+	MaxAge   *int   `json:"maxAge,omitempty"`
+}
+
+// BodySize is a range of response body length in bytes
+type BodySize struct {
+	Min *int64 `json:"min,omitempty"`
+	Max *int64 `json:"max,omitempty"`
+}
+
+// OpenAPIExpect refers operation of OpenAPI spec.
+// Operation is inferred from request method and path if id is not specified.
+type OpenAPIExpect struct {
+	File        string `json:"file"`
+	OperationID string `json:"operationId,omitempty"`
 }
 
 func (e Expect) BodyPath() map[string]interface{} {
@@ -160,7 +446,33 @@ func (e Expect) loadSchemaFromFile(suitePath string) ([]byte, error) {
 	return schema, nil
 }
 
-func (e Expect) loadSchemaFromURI() ([]byte, error) {
+var openAPISpecCache sync.Map
+
+func (e Expect) loadOpenAPISpec(suitePath string) (*OpenAPISpec, error) {
+	path, err := toAbsPath(suitePath, e.OpenAPI.File)
+	if err != nil {
+		return nil, err
+	}
+
+	var cached, ok = openAPISpecCache.Load(path)
+	if ok {
+		debugf("loading OpenAPI spec from the cache: %s", path)
+		return cached.(*OpenAPISpec), nil
+	}
+
+	debugf("loading OpenAPI spec: %s", path)
+
+	spec, err := loadOpenAPISpec(path)
+	if err != nil {
+		return nil, err
+	}
+
+	openAPISpecCache.Store(path, spec)
+
+	return spec, nil
+}
+
+func (e Expect) loadSchemaFromURI(config *RequestConfig) ([]byte, error) {
 	uri := toAbsURL(hostFlag, e.BodySchemaURI)
 
 	if uri == "" {
@@ -176,12 +488,7 @@ func (e Expect) loadSchemaFromURI() ([]byte, error) {
 
 	debugf("loading json schema: %s", uri)
 
-	resp, err := http.Get(uri)
-	if err != nil {
-		return nil, err
-	}
-
-	schema, err := ioutil.ReadAll(resp.Body)
+	schema, err := config.fetch(uri)
 	if err != nil {
 		return nil, err
 	}
@@ -194,11 +501,30 @@ func (e Expect) loadSchemaFromURI() ([]byte, error) {
 func (e *Expect) populateWith(vars *Vars) error {
 	tmplCtx := NewTemplateContext(vars)
 
-	//expect.Headers        map[string]string
-	for name, valueTmpl := range e.Headers {
-		e.Headers[name] = tmplCtx.ApplyTo(valueTmpl)
+	// new maps, templates of shared calls (e.g. beforeEach) and retried calls are populated again
+	if e.Headers != nil {
+		headers := make(map[string]string, len(e.Headers))
+		for name, valueTmpl := range e.Headers {
+			headers[name] = tmplCtx.ApplyTo(valueTmpl)
+		}
+		e.Headers = headers
 	}
 
+	if e.Cookies != nil {
+		cookies := make(map[string]CookieExpect, len(e.Cookies))
+		for name, cookie := range e.Cookies {
+			if cookie.Value != nil {
+				value := tmplCtx.ApplyTo(*cookie.Value)
+				cookie.Value = &value
+			}
+			cookies[name] = cookie
+		}
+		e.Cookies = cookies
+	}
+
+	e.BodySha256 = tmplCtx.ApplyTo(e.BodySha256)
+	e.BodyEqualsFile = tmplCtx.ApplyTo(e.BodyEqualsFile)
+
 	e.Body = populateProperty(tmplCtx, e.Body)
 	e.ExactBody = populateProperty(tmplCtx, e.ExactBody)
 	e.BPath = populateProperty(tmplCtx, e.BodyPath()).(map[string]interface{})
@@ -327,11 +653,22 @@ type CallTrace struct {
 	Num           int
 	RequestMethod string
 	RequestURL    string
+	StatusCode    int
 	RequestDump   string
 	ResponseDump  string
 	ErrorCause    error
 	ExpDesc       map[string]bool
 	ExecFrame     TimeFrame
+	// all tries to make a call, present only when retry policy is defined
+	Attempts []CallAttempt
+}
+
+// CallAttempt describes single try to make a call
+type CallAttempt struct {
+	Num        int
+	StatusCode int
+	ErrorCause error
+	ExecFrame  TimeFrame
 }
 
 func (trace *CallTrace) addExp(desc string) {
@@ -375,6 +712,8 @@ type Response struct {
 	http       *http.Response
 	body       []byte
 	parsedBody interface{}
+	// time spent to send request and receive response
+	execFrame TimeFrame
 }
 
 // Body returns parsed response (array or map) depending on provided 'Content-Type'
@@ -463,6 +802,10 @@ func (resp *Response) ToString() string {
 		body = resp.body
 	}
 
+	if body == nil && len(resp.body) > 0 && !strings.HasPrefix(contentType, "text/") {
+		body = fmt.Sprintf("(%d bytes of %s)", len(resp.body), sniffMediaType(resp.body))
+	} // binary content is not printable
+
 	if body == nil {
 		body = ""
 	}
@@ -482,6 +825,8 @@ type Vars struct {
 	// variables ready to be used
 	items map[string]any
 	used  map[string]bool
+	// directory of the suite, files referenced in templates are relative to it
+	suitePath string
 }
 
 // NewVars create new Vars object with default set of env variables
@@ -506,6 +851,19 @@ func NewVars(baseURL string) *Vars {
 	return v
 }
 
+// Inherit copies user defined variables from the parent scope (e.g. remembered in suite setup).
+// Inherited variables are not reported as unused.
+func (v *Vars) Inherit(parent *Vars) {
+	for name, val := range parent.items {
+		if !parent.isUserDefined(name) {
+			continue
+		}
+
+		v.items[name] = val
+		v.used[name] = true
+	}
+}
+
 func (v *Vars) addContext(name, value string) {
 	v.items[ctxVarPrefix+varPrefixSeparator+name] = value
 }
@@ -739,10 +1097,59 @@ func (t *Throttle) RunOrPause() {
 
 type RequestConfig struct {
 	Headers map[string]string
+	// TLS settings of the HTTP client, default settings are used if nil
+	TLS *tls.Config
+	// cookies shared by the calls, cookies are not stored if nil
+	Jar http.CookieJar
+	// credentials of the requests without Authorization header
+	Auth *Auth
+	// signature headers are added to every request if set
+	Sign *Signing
+	// predefined variables available in every test case
+	Vars map[string]any
+	// time limit of every request, not limited if zero
+	Timeout time.Duration
+	// cancelled once the whole run is timed out, never cancelled if nil
+	Context context.Context
+	// time limit of the whole run, used to explain cancellation
+	RunTimeout time.Duration
+
+	// shared by all clients so connections are reused, default transport if nil
+	transport http.RoundTripper
 }
 
-func newRequestConfig(headersFlag []string) (*RequestConfig, error) {
-	headers := make(map[string]string)
+// newRequestConfig combines environment profile (optional) with command line headers and TLS options.
+// Command line settings take precedence over profile ones.
+func newRequestConfig(headersFlag []string, tlsFlags *TLSOptions, profile *EnvProfile) (*RequestConfig, error) {
+	config := &RequestConfig{Headers: make(map[string]string)}
+
+	var tlsOptions *TLSOptions
+	if profile != nil {
+		for k, v := range profile.Headers {
+			config.Headers[k] = v
+		}
+
+		config.Vars = profile.Vars
+		config.Auth = profile.Auth
+		config.Sign = profile.Sign
+
+		tlsOptions = profile.TLS
+	}
+
+	tlsOptions = tlsOptions.withOverrides(tlsFlags)
+	if tlsOptions != nil {
+		tlsConfig, err := tlsOptions.Config()
+		if err != nil {
+			return nil, err
+		}
+		config.TLS = tlsConfig
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		config.transport = transport
+	}
+
+	headers := config.Headers
 	for _, h := range headersFlag {
 		parts := strings.Split(h, ":")
 		if len(parts) < 2 {
@@ -756,7 +1163,118 @@ func newRequestConfig(headersFlag []string) (*RequestConfig, error) {
 		headers[parts[0]] = parts[1]
 	}
 
-	return &RequestConfig{Headers: headers}, nil
+	return config, nil
+}
+
+// client returns HTTP client configured according to request config
+func (config *RequestConfig) client() *http.Client {
+	return &http.Client{Transport: config.transport, Jar: config.Jar}
+}
+
+// fetch downloads content of the url (e.g. schema) with configured client and request timeout
+func (config *RequestConfig) fetch(url string) ([]byte, error) {
+	ctx, cancel := config.requestContext()
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// cookies of the test case are not shared with schema hosts
+	client := (&RequestConfig{transport: config.transport}).client()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, config.timeoutError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d of %s", resp.StatusCode, url)
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, config.timeoutError(ctx, err)
+	}
+
+	return content, nil
+}
+
+// withAuth returns copy of request config with specified credentials
+func (config *RequestConfig) withAuth(auth *Auth) *RequestConfig {
+	copied := *config
+	copied.Auth = auth
+
+	return &copied
+}
+
+// withSigning returns copy of request config with specified signing
+func (config *RequestConfig) withSigning(sign *Signing) *RequestConfig {
+	copied := *config
+	copied.Sign = sign
+
+	return &copied
+}
+
+// withTimeout returns copy of request config with specified request time limit
+func (config *RequestConfig) withTimeout(timeout time.Duration) *RequestConfig {
+	copied := *config
+	copied.Timeout = timeout
+
+	return &copied
+}
+
+// withRunTimeout returns copy of request config cancelled along with the run context
+func (config *RequestConfig) withRunTimeout(ctx context.Context, timeout time.Duration) *RequestConfig {
+	copied := *config
+	copied.Context = ctx
+	copied.RunTimeout = timeout
+
+	return &copied
+}
+
+// runContext returns context of the whole run
+func (config *RequestConfig) runContext() context.Context {
+	if config.Context == nil {
+		return context.Background()
+	}
+
+	return config.Context
+}
+
+// requestContext returns context of a single request limited by the request timeout
+func (config *RequestConfig) requestContext() (context.Context, context.CancelFunc) {
+	if config.Timeout > 0 {
+		return context.WithTimeout(config.runContext(), config.Timeout)
+	}
+
+	return context.WithCancel(config.runContext())
+}
+
+// timeoutError explains error of the request cancelled by run or request timeout.
+// Other errors are returned as is.
+func (config *RequestConfig) timeoutError(reqCtx context.Context, err error) error {
+	if config.runContext().Err() != nil {
+		return fmt.Errorf("run timeout of %s exceeded", config.RunTimeout)
+	}
+
+	if reqCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("request timed out after %s", config.Timeout)
+	}
+
+	return err
+}
+
+// withCookieJar returns copy of request config with empty cookie jar
+func (config *RequestConfig) withCookieJar() *RequestConfig {
+	jar, _ := cookiejar.New(nil) // never fails without options
+
+	copied := *config
+	copied.Jar = jar
+
+	return &copied
 }
 
 type RewriteConfig struct {
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -297,6 +300,30 @@ func TestExpectPopulateWithHeaders(t *testing.T) {
 	}
 }
 
+func TestExpectPopulateWithSharedExpect(t *testing.T) {
+	cookieTmpl := "{session}"
+	shared := Expect{
+		Headers: map[string]string{"X-Session": "{session}"},
+		Cookies: map[string]CookieExpect{"session": {Value: &cookieTmpl}},
+	} // e.g. beforeEach call or retried call
+
+	for _, session := range []string{"first", "second"} {
+		vars := NewVars("")
+		vars.Add("session", session)
+
+		expect := shared
+		expect.populateWith(vars)
+
+		if expect.Headers["X-Session"] != session || *expect.Cookies["session"].Value != session {
+			t.Errorf("expected %s, headers %v, cookie %v", session, expect.Headers, *expect.Cookies["session"].Value)
+		}
+	}
+
+	if shared.Headers["X-Session"] != "{session}" || *shared.Cookies["session"].Value != "{session}" {
+		t.Errorf("shared expect was modified, headers %v, cookie %v", shared.Headers, *shared.Cookies["session"].Value)
+	}
+}
+
 func TestExpectPopulateWithBody(t *testing.T) {
 
 	path := "items.id"
@@ -374,6 +401,96 @@ func TestOnBodyContentKeepsSingleQuotes(t *testing.T) {
 	}
 }
 
+func TestOnFormContent(t *testing.T) {
+	vars := NewVars("")
+	vars.Add("user", "John Doe")
+
+	on := On{Form: map[string]string{"name": "{user}", "role": "admin&user"}}
+
+	body, contentType, err := on.FormContent("", NewTemplateContext(vars))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if contentType != "application/x-www-form-urlencoded" || body != "name=John+Doe&role=admin%26user" {
+		t.Errorf("unexpected form body %s (%s)", body, contentType)
+	}
+}
+
+func TestOnFormContentNotSpecified(t *testing.T) {
+	_, contentType, err := On{Body: []byte("{}")}.FormContent("", NewTemplateContext(NewVars("")))
+
+	if err != nil || contentType != "" {
+		t.Errorf("unexpected content type %s, error %v", contentType, err)
+	}
+}
+
+func TestOnFormContentWithBody(t *testing.T) {
+	on := On{Body: []byte("{}"), Form: map[string]string{"a": "b"}}
+
+	_, _, err := on.FormContent("", NewTemplateContext(NewVars("")))
+	if err == nil {
+		t.Error("expected error when both body and form are specified")
+	}
+}
+
+func TestOnMultipartContent(t *testing.T) {
+	initLogger()
+
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "avatar.png"), []byte("\x89PNG"), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars := NewVars("")
+	vars.Add("user", "John")
+	vars.Add("dir", dir)
+
+	on := On{Multipart: &Multipart{
+		Fields: map[string]string{"name": "{user}"},
+		Files: []MultipartFile{
+			{Name: "avatar", File: "{dir}/avatar.png"},
+			{Name: "raw", File: "{dir}/avatar.png", FileName: "{user}.bin", ContentType: "application/x-raw"},
+		},
+	}}
+
+	body, contentType, err := on.FormContent("", NewTemplateContext(vars))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("unexpected content type %s", contentType)
+	}
+
+	req := &http.Request{
+		Method: "POST",
+		Header: http.Header{"Content-Type": {contentType}},
+		Body:   ioutil.NopCloser(strings.NewReader(body)),
+	}
+
+	err = req.ParseMultipartForm(1024)
+	if err != nil {
+		t.Fatalf("invalid multipart body with boundary %s: %s", params["boundary"], err)
+	}
+
+	if req.FormValue("name") != "John" {
+		t.Errorf("unexpected field value %s", req.FormValue("name"))
+	}
+
+	avatar := req.MultipartForm.File["avatar"][0]
+	if avatar.Filename != "avatar.png" || avatar.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("unexpected file part %s (%s)", avatar.Filename, avatar.Header.Get("Content-Type"))
+	}
+
+	raw := req.MultipartForm.File["raw"][0]
+	if raw.Filename != "John.bin" || raw.Header.Get("Content-Type") != "application/x-raw" {
+		t.Errorf("unexpected file part %s (%s)", raw.Filename, raw.Header.Get("Content-Type"))
+	}
+}
+
 func TestPopulateProperty_Map(t *testing.T) {
 	vars := NewVars("")
 	_ = vars.Add("username", "dpfg")
@@ -583,3 +700,32 @@ func TestToAbsURLConcat(t *testing.T) {
 		}
 	}
 }
+
+func TestRetryDelayBackoff(t *testing.T) {
+	r := &Retry{Attempts: 3, Interval: "100ms", Backoff: 2}
+
+	for attempt, expected := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond} {
+		d, err := r.delay(attempt)
+		if err != nil || d != expected {
+			t.Errorf("attempt %d: expected %s, got %s (%v)", attempt, expected, d, err)
+		}
+	}
+
+	if _, err := (&Retry{Interval: "soon"}).delay(1); err == nil {
+		t.Error("expected invalid interval error")
+	}
+}
+
+func TestWithMaxDuration(t *testing.T) {
+	calls := []Call{{}, {Expect: Expect{MaxDuration: "1s"}}}
+
+	result := withMaxDuration(calls, "300ms")
+
+	if result[0].Expect.MaxDuration != "300ms" || result[1].Expect.MaxDuration != "1s" {
+		t.Errorf("unexpected durations: %s, %s", result[0].Expect.MaxDuration, result[1].Expect.MaxDuration)
+	}
+
+	if calls[0].Expect.MaxDuration != "" {
+		t.Error("original calls should not be modified")
+	}
+}
@@ -0,0 +1,1234 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Subset of XML Schema 1.0 sufficient for contract testing of XML and SOAP services:
+// elements, attributes, complex types (sequence, choice, all, group, simple and complex content),
+// simple types (built-in types, restriction facets, list, union), include and import.
+// Identity constraints, substitution groups and xsi:type are not supported.
+
+const (
+	xsdNamespace = "http://www.w3.org/2001/XMLSchema"
+	xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+	soap11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+
+	maxXSDErrors = 20
+)
+
+// xmlNode is a generic element tree with namespaces in scope
+type xmlNode struct {
+	Name     xml.Name
+	Attrs    []xml.Attr
+	Children []*xmlNode
+	Text     string
+
+	parent *xmlNode
+	ns     map[string]string
+	// root of the schema document, used for schema nodes only
+	schema *xmlNode
+}
+
+func parseXMLTree(r io.Reader) (*xmlNode, error) {
+	decoder := xml.NewDecoder(r)
+
+	var root, current *xmlNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch typed := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{Name: typed.Name, Attrs: typed.Attr, parent: current, ns: map[string]string{}}
+			if current != nil {
+				for k, v := range current.ns {
+					node.ns[k] = v
+				}
+				current.Children = append(current.Children, node)
+			} else {
+				root = node
+			}
+
+			for _, a := range typed.Attr {
+				if a.Name.Space == "xmlns" {
+					node.ns[a.Name.Local] = a.Value
+				} else if a.Name.Space == "" && a.Name.Local == "xmlns" {
+					node.ns[""] = a.Value
+				}
+			}
+
+			current = node
+
+		case xml.EndElement:
+			current = current.parent
+
+		case xml.CharData:
+			if current != nil {
+				current.Text += string(typed)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("document has no root element")
+	}
+
+	return root, nil
+}
+
+// attr returns value of unqualified attribute
+func (n *xmlNode) attr(name string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+func (n *xmlNode) hasAttr(name string) bool {
+	for _, a := range n.Attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// qname resolves prefixed name (e.g. tns:User) using namespaces in scope of the node
+func (n *xmlNode) qname(value string) xml.Name {
+	prefix, local := "", value
+	if i := strings.Index(value, ":"); i >= 0 {
+		prefix, local = value[:i], value[i+1:]
+	}
+
+	return xml.Name{Space: n.ns[prefix], Local: local}
+}
+
+// xsdChildren returns schema components declared in the node, annotations are skipped
+func (n *xmlNode) xsdChildren() []*xmlNode {
+	children := make([]*xmlNode, 0, len(n.Children))
+	for _, c := range n.Children {
+		if c.Name.Space == xsdNamespace && c.Name.Local != "annotation" {
+			children = append(children, c)
+		}
+	}
+
+	return children
+}
+
+func (n *xmlNode) xsdChild(names ...string) *xmlNode {
+	for _, c := range n.xsdChildren() {
+		for _, name := range names {
+			if c.Name.Local == name {
+				return c
+			}
+		}
+	}
+
+	return nil
+}
+
+// XSDSchema is a set of schema documents (main one plus included and imported)
+type XSDSchema struct {
+	docs  []*xmlNode
+	fetch func(url string) ([]byte, error)
+}
+
+// LoadXSD parses schema, location (file path or url) is used to resolve included and imported schemas.
+// Remote schemas are downloaded with fetch.
+func LoadXSD(content []byte, location string, fetch func(url string) ([]byte, error)) (*XSDSchema, error) {
+	schema := &XSDSchema{fetch: fetch}
+
+	err := schema.add(content, location, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+func (s *XSDSchema) add(content []byte, location string, loaded map[string]bool) error {
+	root, err := parseXMLTree(bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("invalid XSD %s: %s", location, err)
+	}
+
+	if root.Name.Space != xsdNamespace || root.Name.Local != "schema" {
+		return fmt.Errorf("invalid XSD %s: root element is not xs:schema", location)
+	}
+
+	markSchema(root, root)
+	s.docs = append(s.docs, root)
+	loaded[location] = true
+
+	for _, c := range root.xsdChildren() {
+		if c.Name.Local != "include" && c.Name.Local != "import" && c.Name.Local != "redefine" {
+			continue
+		}
+
+		ref := c.attr("schemaLocation")
+		if ref == "" {
+			continue
+		} // namespace is expected to be resolved by other means, e.g. well-known one
+
+		nested, err := resolveLocation(location, ref)
+		if err != nil {
+			return err
+		}
+
+		if loaded[nested] {
+			continue
+		}
+
+		nestedContent, err := s.readLocation(nested)
+		if err != nil {
+			return fmt.Errorf("cannot load XSD %s: %s", nested, err)
+		}
+
+		err = s.add(nestedContent, nested, loaded)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func markSchema(node *xmlNode, schema *xmlNode) {
+	node.schema = schema
+	for _, c := range node.Children {
+		markSchema(c, schema)
+	}
+}
+
+func resolveLocation(base, ref string) (string, error) {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") || filepath.IsAbs(ref) {
+		return ref, nil
+	}
+
+	if strings.HasPrefix(base, "http://") || strings.HasPrefix(base, "https://") {
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			return "", err
+		}
+
+		refURL, err := url.Parse(ref)
+		if err != nil {
+			return "", err
+		}
+
+		return baseURL.ResolveReference(refURL).String(), nil
+	}
+
+	if base == "" {
+		return "", fmt.Errorf("cannot resolve relative schema location %s of inline schema", ref)
+	}
+
+	return filepath.Join(filepath.Dir(base), ref), nil
+}
+
+func (s *XSDSchema) readLocation(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return ioutil.ReadFile(location)
+	}
+
+	return s.fetch(location)
+}
+
+// global finds top level component (e.g. element, complexType) by qualified name
+func (s *XSDSchema) global(kind string, name xml.Name) *xmlNode {
+	for _, doc := range s.docs {
+		if doc.attr("targetNamespace") != name.Space && !(name.Space == "" && !doc.hasAttr("targetNamespace")) {
+			continue
+		}
+
+		for _, c := range doc.xsdChildren() {
+			if c.Name.Local == kind && c.attr("name") == name.Local {
+				return c
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate checks XML document against the schema. SOAP envelope is skipped unless described by the schema.
+func (s *XSDSchema) Validate(document []byte) []string {
+	root, err := parseXMLTree(bytes.NewReader(document))
+	if err != nil {
+		return []string{fmt.Sprintf("invalid XML: %s", err)}
+	}
+
+	v := &xsdValidator{schema: s}
+
+	isSOAP := root.Name.Local == "Envelope" && (root.Name.Space == soap11Namespace || root.Name.Space == soap12Namespace)
+	if isSOAP && s.global("element", root.Name) == nil {
+		for _, c := range root.Children {
+			if c.Name.Local != "Body" || c.Name.Space != root.Name.Space {
+				continue
+			}
+
+			for i, payload := range c.Children {
+				v.validateRoot(payload, "/Envelope/Body"+elementPath(c.Children, i))
+			}
+		}
+
+		return v.errs
+	}
+
+	v.validateRoot(root, elementPath([]*xmlNode{root}, 0))
+
+	return v.errs
+}
+
+type xsdValidator struct {
+	schema *XSDSchema
+	errs   []string
+
+	// the furthest child position content matching stopped at and elements expected there
+	stopPos  int
+	expected []string
+}
+
+// expectAt remembers element expected at the child position the matching failed at
+func (v *xsdValidator) expectAt(pos int, name string) {
+	if pos < v.stopPos {
+		return
+	}
+
+	if pos > v.stopPos {
+		v.stopPos, v.expected = pos, nil
+	}
+
+	for _, e := range v.expected {
+		if e == name {
+			return
+		}
+	}
+
+	v.expected = append(v.expected, name)
+}
+
+func (v *xsdValidator) errorf(path string, format string, args ...interface{}) {
+	if len(v.errs) == maxXSDErrors {
+		v.errs = append(v.errs, "too many errors")
+	}
+	if len(v.errs) > maxXSDErrors {
+		return
+	}
+
+	v.errs = append(v.errs, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *xsdValidator) validateRoot(inst *xmlNode, path string) {
+	decl := v.schema.global("element", inst.Name)
+	if decl == nil {
+		v.errorf(path, "no global element %s is declared", formatName(inst.Name))
+		return
+	}
+
+	v.validateElement(inst, decl, path)
+}
+
+// elementPath returns path segment of the element, index is added if there are siblings with the same name
+func elementPath(siblings []*xmlNode, i int) string {
+	name := siblings[i].Name.Local
+
+	index, count := 0, 0
+	for j, s := range siblings {
+		if s.Name == siblings[i].Name {
+			count++
+			if j <= i {
+				index++
+			}
+		}
+	}
+
+	if count > 1 {
+		return fmt.Sprintf("/%s[%d]", name, index)
+	}
+
+	return "/" + name
+}
+
+func formatName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return "{" + name.Space + "}" + name.Local
+}
+
+// elementName returns expected qualified name of the declared element
+func (v *xsdValidator) elementName(decl *xmlNode) xml.Name {
+	schema := decl.schema
+	name := xml.Name{Local: decl.attr("name")}
+
+	qualified := decl.parent == schema
+	if form := decl.attr("form"); form != "" {
+		qualified = qualified || form == "qualified"
+	} else {
+		qualified = qualified || schema.attr("elementFormDefault") == "qualified"
+	}
+
+	if qualified {
+		name.Space = schema.attr("targetNamespace")
+	}
+
+	return name
+}
+
+// resolveElement returns declaration referenced by the element particle
+func (v *xsdValidator) resolveElement(decl *xmlNode) *xmlNode {
+	if ref := decl.attr("ref"); ref != "" {
+		return v.schema.global("element", decl.qname(ref))
+	}
+
+	return decl
+}
+
+func (v *xsdValidator) validateElement(inst *xmlNode, decl *xmlNode, path string) {
+	for _, a := range inst.Attrs {
+		if a.Name.Space == xsiNamespace && a.Name.Local == "nil" && (a.Value == "true" || a.Value == "1") {
+			if decl.attr("nillable") != "true" {
+				v.errorf(path, "element is not nillable")
+			} else if len(inst.Children) > 0 || strings.TrimSpace(inst.Text) != "" {
+				v.errorf(path, "nil element must be empty")
+			}
+			return
+		}
+	}
+
+	if typeName := decl.attr("type"); typeName != "" {
+		v.validateTyped(inst, decl.qname(typeName), decl, path)
+		return
+	}
+
+	if ct := decl.xsdChild("complexType"); ct != nil {
+		v.validateComplex(inst, ct, path)
+		return
+	}
+
+	if st := decl.xsdChild("simpleType"); st != nil {
+		v.validateSimpleElement(inst, path, func(value string) error { return v.checkSimpleType(st, value) })
+		return
+	}
+
+	// anyType, any content is allowed
+}
+
+// validateTyped validates element of the named type
+func (v *xsdValidator) validateTyped(inst *xmlNode, typeName xml.Name, ctx *xmlNode, path string) {
+	if typeName.Space == xsdNamespace {
+		if typeName.Local == "anyType" {
+			return
+		}
+
+		v.validateSimpleElement(inst, path, func(value string) error { return checkBuiltinType(typeName.Local, value) })
+		return
+	}
+
+	if ct := v.schema.global("complexType", typeName); ct != nil {
+		v.validateComplex(inst, ct, path)
+		return
+	}
+
+	if st := v.schema.global("simpleType", typeName); st != nil {
+		v.validateSimpleElement(inst, path, func(value string) error { return v.checkSimpleType(st, value) })
+		return
+	}
+
+	v.errorf(path, "type %s is not declared", formatName(typeName))
+}
+
+func (v *xsdValidator) validateSimpleElement(inst *xmlNode, path string, check func(string) error) {
+	if len(inst.Children) > 0 {
+		v.errorf(path, "element of simple type can't have child elements")
+		return
+	}
+
+	for _, a := range instanceAttrs(inst) {
+		v.errorf(path, "attribute %s is not allowed", a.Name.Local)
+	}
+
+	if err := check(inst.Text); err != nil {
+		v.errorf(path, "%s", err)
+	}
+}
+
+// instanceAttrs returns attributes to validate, namespace declarations and xsi attributes are excluded
+func instanceAttrs(inst *xmlNode) []xml.Attr {
+	attrs := make([]xml.Attr, 0, len(inst.Attrs))
+	for _, a := range inst.Attrs {
+		if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") || a.Name.Space == xsiNamespace || a.Name.Space == "xml" {
+			continue
+		}
+		attrs = append(attrs, a)
+	}
+
+	return attrs
+}
+
+// complexContent is effective content of the complex type after extensions are applied
+type complexContent struct {
+	particles   []*xmlNode
+	attributes  []*xmlNode
+	anyAttr     bool
+	mixed       bool
+	simpleCheck func(string) error
+}
+
+func (v *xsdValidator) collectComplex(ct *xmlNode, content *complexContent, depth int) {
+	if depth > 32 {
+		return
+	} // circular type derivation
+
+	if ct.attr("mixed") == "true" {
+		content.mixed = true
+	}
+
+	for _, c := range ct.xsdChildren() {
+		switch c.Name.Local {
+		case "sequence", "choice", "all", "group":
+			content.particles = append(content.particles, c)
+
+		case "attribute", "attributeGroup", "anyAttribute":
+			v.collectAttribute(c, content, depth)
+
+		case "simpleContent":
+			v.collectSimpleContent(c, content, depth)
+
+		case "complexContent":
+			if c.attr("mixed") == "true" {
+				content.mixed = true
+			}
+
+			derivation := c.xsdChild("extension", "restriction")
+			if derivation == nil {
+				continue
+			}
+
+			if derivation.Name.Local == "extension" {
+				base := derivation.qname(derivation.attr("base"))
+				if baseType := v.schema.global("complexType", base); baseType != nil {
+					v.collectComplex(baseType, content, depth+1)
+				}
+			}
+
+			v.collectComplex(derivation, content, depth+1)
+		}
+	}
+}
+
+func (v *xsdValidator) collectSimpleContent(sc *xmlNode, content *complexContent, depth int) {
+	derivation := sc.xsdChild("extension", "restriction")
+	if derivation == nil {
+		return
+	}
+
+	base := derivation.qname(derivation.attr("base"))
+	if baseType := v.schema.global("complexType", base); baseType != nil {
+		v.collectComplex(baseType, content, depth+1)
+	} else {
+		content.simpleCheck = func(value string) error { return v.checkNamedSimpleType(base, value) }
+	}
+
+	if derivation.Name.Local == "restriction" && hasFacets(derivation) {
+		baseCheck := content.simpleCheck
+		content.simpleCheck = func(value string) error {
+			if baseCheck != nil {
+				if err := baseCheck(value); err != nil {
+					return err
+				}
+			}
+			return checkFacets(derivation, value)
+		}
+	}
+
+	for _, c := range derivation.xsdChildren() {
+		v.collectAttribute(c, content, depth)
+	}
+}
+
+func (v *xsdValidator) collectAttribute(c *xmlNode, content *complexContent, depth int) {
+	switch c.Name.Local {
+	case "attribute":
+		content.attributes = append(content.attributes, c)
+
+	case "anyAttribute":
+		content.anyAttr = true
+
+	case "attributeGroup":
+		group := c
+		if ref := c.attr("ref"); ref != "" {
+			group = v.schema.global("attributeGroup", c.qname(ref))
+		}
+
+		if group != nil && depth < 32 {
+			for _, gc := range group.xsdChildren() {
+				v.collectAttribute(gc, content, depth+1)
+			}
+		}
+	}
+}
+
+func (v *xsdValidator) validateComplex(inst *xmlNode, ct *xmlNode, path string) {
+	content := &complexContent{}
+	v.collectComplex(ct, content, 0)
+
+	v.validateAttributes(inst, content, path)
+
+	if content.simpleCheck != nil {
+		if len(inst.Children) > 0 {
+			v.errorf(path, "element with simple content can't have child elements")
+			return
+		}
+
+		if err := content.simpleCheck(inst.Text); err != nil {
+			v.errorf(path, "%s", err)
+		}
+		return
+	}
+
+	if !content.mixed && strings.TrimSpace(inst.Text) != "" {
+		v.errorf(path, "text content is not allowed")
+	}
+
+	var matches []xsdMatch
+	pos, ok := 0, true
+	v.stopPos, v.expected = -1, nil
+	for _, p := range content.particles {
+		pos, ok = v.matchParticle(p, inst.Children, pos, &matches)
+		if !ok {
+			break
+		}
+	}
+
+	if !ok {
+		v.reportMismatch(inst, content.particles, path)
+	} else if pos < len(inst.Children) {
+		v.errorf(path+elementPath(inst.Children, pos), "element is not expected")
+	}
+
+	for _, m := range matches {
+		childPath := path + elementPath(inst.Children, m.pos)
+		if m.decl == nil {
+			continue
+		} // wildcard with skipped processing
+
+		v.validateElement(inst.Children[m.pos], m.decl, childPath)
+	}
+}
+
+// reportMismatch reports required elements which are not present at all,
+// otherwise the child element the matching stopped at (e.g. out of order) and elements expected there
+func (v *xsdValidator) reportMismatch(inst *xmlNode, particles []*xmlNode, path string) {
+	if missing := v.missingElements(particles, inst.Children); len(missing) > 0 {
+		v.errorf(path, "content does not match the schema, missing required element(s): %s", strings.Join(missing, ", "))
+		return
+	}
+
+	expected := strings.Join(v.expected, ", ")
+	if len(v.expected) > 1 {
+		expected = "one of " + expected
+	}
+
+	if v.stopPos >= 0 && v.stopPos < len(inst.Children) {
+		v.errorf(path+elementPath(inst.Children, v.stopPos), "element is not expected here, expected %s", expected)
+		return
+	}
+
+	v.errorf(path, "content does not match the schema, expected %s after the last element", expected)
+}
+
+func (v *xsdValidator) validateAttributes(inst *xmlNode, content *complexContent, path string) {
+	declared := map[string]bool{}
+
+	for _, decl := range content.attributes {
+		attrDecl := decl
+		if ref := decl.attr("ref"); ref != "" {
+			attrDecl = v.schema.global("attribute", decl.qname(ref))
+			if attrDecl == nil {
+				v.errorf(path, "attribute %s is not declared", ref)
+				continue
+			}
+		}
+
+		name := attrDecl.attr("name")
+		declared[name] = true
+
+		var value *string
+		for _, a := range instanceAttrs(inst) {
+			if a.Name.Local == name {
+				val := a.Value
+				value = &val
+				break
+			}
+		}
+
+		if value == nil {
+			if decl.attr("use") == "required" {
+				v.errorf(path, "required attribute %s is missing", name)
+			}
+			continue
+		}
+
+		if decl.attr("use") == "prohibited" {
+			v.errorf(path, "attribute %s is prohibited", name)
+			continue
+		}
+
+		var err error
+		if typeName := attrDecl.attr("type"); typeName != "" {
+			err = v.checkNamedSimpleType(attrDecl.qname(typeName), *value)
+		} else if st := attrDecl.xsdChild("simpleType"); st != nil {
+			err = v.checkSimpleType(st, *value)
+		}
+
+		if fixed := attrDecl.attr("fixed"); err == nil && attrDecl.hasAttr("fixed") && *value != fixed {
+			err = fmt.Errorf("value '%s' is not equal to fixed value '%s'", *value, fixed)
+		}
+
+		if err != nil {
+			v.errorf(path+"/@"+name, "%s", err)
+		}
+	}
+
+	if content.anyAttr {
+		return
+	}
+
+	for _, a := range instanceAttrs(inst) {
+		if !declared[a.Name.Local] {
+			v.errorf(path+"/@"+a.Name.Local, "attribute is not declared")
+		}
+	}
+}
+
+// xsdMatch is a child element assigned to the element declaration
+type xsdMatch struct {
+	pos  int
+	decl *xmlNode
+}
+
+func occurs(p *xmlNode) (int, int) {
+	min, max := 1, 1
+
+	if value := p.attr("minOccurs"); value != "" {
+		min, _ = strconv.Atoi(value)
+	}
+
+	if value := p.attr("maxOccurs"); value == "unbounded" {
+		max = -1
+	} else if value != "" {
+		max, _ = strconv.Atoi(value)
+	}
+
+	return min, max
+}
+
+// matchParticle greedily assigns children starting from pos to the particle.
+// Returns position after the last consumed child and whether minimum occurrence is satisfied.
+func (v *xsdValidator) matchParticle(p *xmlNode, children []*xmlNode, pos int, matches *[]xsdMatch) (int, bool) {
+	min, max := occurs(p)
+
+	count := 0
+	for max < 0 || count < max {
+		mark := len(*matches)
+
+		next, ok := v.matchOnce(p, children, pos, matches)
+		if !ok {
+			*matches = (*matches)[:mark]
+			break
+		}
+
+		if next == pos {
+			count = min
+			break
+		} // empty match satisfies any number of occurrences
+
+		pos = next
+		count++
+	}
+
+	return pos, count >= min
+}
+
+func (v *xsdValidator) matchOnce(p *xmlNode, children []*xmlNode, pos int, matches *[]xsdMatch) (int, bool) {
+	switch p.Name.Local {
+	case "element":
+		decl := v.resolveElement(p)
+		if decl == nil {
+			return pos, false
+		}
+
+		if pos >= len(children) || children[pos].Name != v.elementName(decl) {
+			v.expectAt(pos, v.elementName(decl).Local)
+			return pos, false
+		}
+
+		*matches = append(*matches, xsdMatch{pos: pos, decl: decl})
+		return pos + 1, true
+
+	case "any":
+		if pos >= len(children) || !namespaceAllowed(p, children[pos].Name.Space) {
+			v.expectAt(pos, "any element")
+			return pos, false
+		}
+
+		var decl *xmlNode
+		if p.attr("processContents") != "skip" {
+			decl = v.schema.global("element", children[pos].Name)
+		}
+
+		*matches = append(*matches, xsdMatch{pos: pos, decl: decl})
+		return pos + 1, true
+
+	case "sequence":
+		start := pos
+		for _, c := range p.xsdChildren() {
+			var ok bool
+			pos, ok = v.matchParticle(c, children, pos, matches)
+			if !ok {
+				return start, false
+			}
+		}
+		return pos, true
+
+	case "choice":
+		empty := false
+		for _, c := range p.xsdChildren() {
+			mark := len(*matches)
+
+			next, ok := v.matchParticle(c, children, pos, matches)
+			if ok && next > pos {
+				return next, true
+			}
+
+			*matches = (*matches)[:mark]
+			empty = empty || ok
+		}
+		return pos, empty
+
+	case "all":
+		elements := p.xsdChildren()
+		used := make([]bool, len(elements))
+
+		for pos < len(children) {
+			found := false
+			for i, c := range elements {
+				if used[i] {
+					continue
+				}
+
+				decl := v.resolveElement(c)
+				if decl != nil && children[pos].Name == v.elementName(decl) {
+					*matches = append(*matches, xsdMatch{pos: pos, decl: decl})
+					used[i], found = true, true
+					pos++
+					break
+				}
+			}
+
+			if !found {
+				break
+			}
+		}
+
+		complete := true
+		for i, c := range elements {
+			if min, _ := occurs(c); !used[i] && min > 0 {
+				if decl := v.resolveElement(c); decl != nil {
+					v.expectAt(pos, v.elementName(decl).Local)
+				}
+				complete = false
+			}
+		}
+		return pos, complete
+
+	case "group":
+		group := p
+		if ref := p.attr("ref"); ref != "" {
+			group = v.schema.global("group", p.qname(ref))
+		}
+		if group == nil {
+			return pos, false
+		}
+
+		model := group.xsdChild("sequence", "choice", "all")
+		if model == nil {
+			return pos, true
+		}
+
+		return v.matchParticle(model, children, pos, matches)
+	}
+
+	return pos, false
+}
+
+func namespaceAllowed(any *xmlNode, space string) bool {
+	namespaces := any.attr("namespace")
+	tns := any.schema.attr("targetNamespace")
+
+	switch namespaces {
+	case "", "##any":
+		return true
+	case "##other":
+		return space != tns && space != ""
+	}
+
+	for _, ns := range strings.Fields(namespaces) {
+		if (ns == "##targetNamespace" && space == tns) || (ns == "##local" && space == "") || ns == space {
+			return true
+		}
+	}
+
+	return false
+}
+
+// missingElements lists required elements of the top level sequence which are not present
+func (v *xsdValidator) missingElements(particles []*xmlNode, children []*xmlNode) []string {
+	present := map[string]bool{}
+	for _, c := range children {
+		present[c.Name.Local] = true
+	}
+
+	missing := []string{}
+
+	var collect func(p *xmlNode, depth int)
+	collect = func(p *xmlNode, depth int) {
+		if min, _ := occurs(p); min == 0 || depth > 8 {
+			return
+		}
+
+		switch p.Name.Local {
+		case "element":
+			decl := v.resolveElement(p)
+			if decl != nil && !present[decl.attr("name")] {
+				missing = append(missing, decl.attr("name"))
+			}
+		case "sequence", "all":
+			for _, c := range p.xsdChildren() {
+				collect(c, depth+1)
+			}
+		case "group":
+			if group := v.schema.global("group", p.qname(p.attr("ref"))); group != nil {
+				if model := group.xsdChild("sequence", "choice", "all"); model != nil {
+					collect(model, depth+1)
+				}
+			}
+		case "choice":
+			names := []string{}
+			for _, c := range p.xsdChildren() {
+				if c.Name.Local == "element" {
+					if decl := v.resolveElement(c); decl != nil {
+						if present[decl.attr("name")] {
+							return
+						}
+						names = append(names, decl.attr("name"))
+					}
+				}
+			}
+			if len(names) > 0 {
+				missing = append(missing, "one of "+strings.Join(names, "|"))
+			}
+		}
+	}
+
+	for _, p := range particles {
+		collect(p, 0)
+	}
+
+	return missing
+}
+
+// checkNamedSimpleType validates value against built-in or declared simple type
+func (v *xsdValidator) checkNamedSimpleType(name xml.Name, value string) error {
+	if name.Space == xsdNamespace {
+		return checkBuiltinType(name.Local, value)
+	}
+
+	st := v.schema.global("simpleType", name)
+	if st == nil {
+		return fmt.Errorf("simple type %s is not declared", formatName(name))
+	}
+
+	return v.checkSimpleType(st, value)
+}
+
+func (v *xsdValidator) checkSimpleType(st *xmlNode, value string) error {
+	if restriction := st.xsdChild("restriction"); restriction != nil {
+		if base := restriction.attr("base"); base != "" {
+			if err := v.checkNamedSimpleType(restriction.qname(base), value); err != nil {
+				return err
+			}
+		} else if inner := restriction.xsdChild("simpleType"); inner != nil {
+			if err := v.checkSimpleType(inner, value); err != nil {
+				return err
+			}
+		}
+
+		return checkFacets(restriction, value)
+	}
+
+	if list := st.xsdChild("list"); list != nil {
+		for _, item := range strings.Fields(value) {
+			var err error
+			if itemType := list.attr("itemType"); itemType != "" {
+				err = v.checkNamedSimpleType(list.qname(itemType), item)
+			} else if inner := list.xsdChild("simpleType"); inner != nil {
+				err = v.checkSimpleType(inner, item)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if union := st.xsdChild("union"); union != nil {
+		for _, member := range strings.Fields(union.attr("memberTypes")) {
+			if v.checkNamedSimpleType(union.qname(member), value) == nil {
+				return nil
+			}
+		}
+
+		for _, inner := range union.xsdChildren() {
+			if inner.Name.Local == "simpleType" && v.checkSimpleType(inner, value) == nil {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("value '%s' does not match any member type of the union", value)
+	}
+
+	return nil
+}
+
+func hasFacets(restriction *xmlNode) bool {
+	for _, c := range restriction.xsdChildren() {
+		if c.Name.Local != "attribute" && c.Name.Local != "attributeGroup" && c.Name.Local != "anyAttribute" && c.Name.Local != "simpleType" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkFacets(restriction *xmlNode, value string) error {
+	collapsed := strings.Join(strings.Fields(value), " ")
+
+	var enumeration []string
+	var patterns []string
+
+	for _, facet := range restriction.xsdChildren() {
+		facetValue := facet.attr("value")
+
+		switch facet.Name.Local {
+		case "enumeration":
+			enumeration = append(enumeration, facetValue)
+
+		case "pattern":
+			patterns = append(patterns, facetValue)
+
+		case "length", "minLength", "maxLength":
+			limit, _ := strconv.Atoi(facetValue)
+			length := utf8.RuneCountInString(value)
+
+			if (facet.Name.Local == "length" && length != limit) ||
+				(facet.Name.Local == "minLength" && length < limit) ||
+				(facet.Name.Local == "maxLength" && length > limit) {
+				return fmt.Errorf("length of value '%s' is %d, %s is %s", value, length, facet.Name.Local, facetValue)
+			}
+
+		case "minInclusive", "maxInclusive", "minExclusive", "maxExclusive":
+			c, ok := compareXSDValues(collapsed, facetValue)
+			if !ok {
+				continue
+			}
+
+			if (facet.Name.Local == "minInclusive" && c < 0) ||
+				(facet.Name.Local == "maxInclusive" && c > 0) ||
+				(facet.Name.Local == "minExclusive" && c <= 0) ||
+				(facet.Name.Local == "maxExclusive" && c >= 0) {
+				return fmt.Errorf("value '%s' violates %s %s", collapsed, facet.Name.Local, facetValue)
+			}
+
+		case "totalDigits", "fractionDigits":
+			limit, _ := strconv.Atoi(facetValue)
+			digits := strings.TrimLeft(strings.TrimLeft(collapsed, "+-"), "0")
+
+			count := 0
+			if facet.Name.Local == "totalDigits" {
+				count = len(strings.Replace(digits, ".", "", 1))
+			} else if i := strings.Index(digits, "."); i >= 0 {
+				count = len(strings.TrimRight(digits[i+1:], "0"))
+			}
+
+			if count > limit {
+				return fmt.Errorf("value '%s' has more than %d %s", collapsed, limit, facet.Name.Local)
+			}
+		}
+	}
+
+	if len(enumeration) > 0 {
+		found := false
+		for _, e := range enumeration {
+			if e == collapsed || e == value {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("value '%s' is not one of %s", collapsed, strings.Join(enumeration, ", "))
+		}
+	}
+
+	if len(patterns) > 0 {
+		matched := false
+		for _, p := range patterns {
+			re, err := regexp.Compile("^(?:" + p + ")$")
+			if err != nil || re.MatchString(value) {
+				matched = true
+				break
+			} // unsupported XSD regex syntax is ignored
+		}
+
+		if !matched {
+			return fmt.Errorf("value '%s' does not match pattern %s", value, strings.Join(patterns, " | "))
+		}
+	}
+
+	return nil
+}
+
+// compareXSDValues compares numbers or, if not numbers, strings (e.g. dates)
+func compareXSDValues(a, b string) (int, bool) {
+	af, aok := new(big.Float).SetString(a)
+	bf, bok := new(big.Float).SetString(b)
+
+	if aok && bok {
+		return af.Cmp(bf), true
+	}
+
+	if aok != bok {
+		return 0, false
+	}
+
+	return strings.Compare(a, b), true
+}
+
+var (
+	decimalRegexp  = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	integerRegexp  = regexp.MustCompile(`^[+-]?\d+$`)
+	dateRegexp     = regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}(Z|[+-]\d{2}:\d{2})?$`)
+	dateTimeRegexp = regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`)
+	timeRegexp     = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`)
+	durationRegexp = regexp.MustCompile(`^-?P(\d+Y)?(\d+M)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`)
+)
+
+// integer types with their inclusive ranges, empty bound is unlimited
+var integerRanges = map[string][2]string{
+	"integer":            {"", ""},
+	"long":               {"-9223372036854775808", "9223372036854775807"},
+	"int":                {"-2147483648", "2147483647"},
+	"short":              {"-32768", "32767"},
+	"byte":               {"-128", "127"},
+	"nonNegativeInteger": {"0", ""},
+	"positiveInteger":    {"1", ""},
+	"nonPositiveInteger": {"", "0"},
+	"negativeInteger":    {"", "-1"},
+	"unsignedLong":       {"0", "18446744073709551615"},
+	"unsignedInt":        {"0", "4294967295"},
+	"unsignedShort":      {"0", "65535"},
+	"unsignedByte":       {"0", "255"},
+}
+
+// checkBuiltinType validates value of XML Schema built-in simple type
+func checkBuiltinType(name string, value string) error {
+	collapsed := strings.TrimSpace(value)
+
+	valid := true
+	switch name {
+	case "boolean":
+		valid = collapsed == "true" || collapsed == "false" || collapsed == "1" || collapsed == "0"
+
+	case "decimal":
+		valid = decimalRegexp.MatchString(collapsed)
+
+	case "float", "double":
+		_, err := strconv.ParseFloat(collapsed, 64)
+		valid = err == nil || collapsed == "INF" || collapsed == "-INF" || collapsed == "NaN"
+
+	case "date":
+		valid = dateRegexp.MatchString(collapsed)
+
+	case "dateTime":
+		valid = dateTimeRegexp.MatchString(collapsed)
+
+	case "time":
+		valid = timeRegexp.MatchString(collapsed)
+
+	case "duration":
+		valid = collapsed != "P" && collapsed != "-P" && !strings.HasSuffix(collapsed, "T") && durationRegexp.MatchString(collapsed)
+
+	case "base64Binary":
+		_, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+		valid = err == nil
+
+	case "hexBinary":
+		_, err := hex.DecodeString(collapsed)
+		valid = err == nil
+
+	default:
+		bounds, ok := integerRanges[name]
+		if !ok {
+			return nil
+		} // string based types
+
+		if !integerRegexp.MatchString(collapsed) {
+			valid = false
+			break
+		}
+
+		if c, _ := compareXSDValues(collapsed, bounds[0]); bounds[0] != "" && c < 0 {
+			valid = false
+		}
+		if c, _ := compareXSDValues(collapsed, bounds[1]); bounds[1] != "" && c > 0 {
+			valid = false
+		}
+	}
+
+	if !valid {
+		return fmt.Errorf("value '%s' is not a valid %s", value, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testXSD = `<?xml version="1.0"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"
+           xmlns:tns="http://example.com/users"
+           targetNamespace="http://example.com/users"
+           elementFormDefault="qualified">
+  <xs:include schemaLocation="types.xsd"/>
+
+  <xs:element name="users">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="user" type="tns:User" minOccurs="0" maxOccurs="unbounded"/>
+        <xs:element name="admin" type="tns:Admin" minOccurs="0" maxOccurs="unbounded"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+
+  <xs:complexType name="User">
+    <xs:sequence>
+      <xs:element name="name" type="xs:string"/>
+      <xs:element name="age" type="xs:nonNegativeInteger" minOccurs="0"/>
+      <xs:choice>
+        <xs:element name="email" type="xs:string"/>
+        <xs:element name="phone" type="xs:string"/>
+      </xs:choice>
+      <xs:element name="role" type="tns:Role" nillable="true"/>
+    </xs:sequence>
+    <xs:attribute name="id" type="xs:int" use="required"/>
+  </xs:complexType>
+
+  <xs:complexType name="Admin">
+    <xs:complexContent>
+      <xs:extension base="tns:User">
+        <xs:sequence>
+          <xs:element name="permissions" type="xs:string"/>
+        </xs:sequence>
+      </xs:extension>
+    </xs:complexContent>
+  </xs:complexType>
+</xs:schema>`
+
+const testTypesXSD = `<?xml version="1.0"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="http://example.com/users">
+  <xs:simpleType name="Role">
+    <xs:restriction base="xs:string">
+      <xs:enumeration value="admin"/>
+      <xs:enumeration value="user"/>
+    </xs:restriction>
+  </xs:simpleType>
+</xs:schema>`
+
+func loadTestXSD(t *testing.T) *XSDSchema {
+	dir := t.TempDir()
+
+	err := ioutil.WriteFile(filepath.Join(dir, "types.xsd"), []byte(testTypesXSD), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := LoadXSD([]byte(testXSD), filepath.Join(dir, "users.xsd"), (&RequestConfig{}).fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
+
+func TestXSDValidate(t *testing.T) {
+	schema := loadTestXSD(t)
+
+	user := func(content string) string {
+		return `<users xmlns="http://example.com/users" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` + content + `</users>`
+	}
+
+	tests := []struct {
+		name     string
+		document string
+		wantErr  string
+	}{
+		{name: "valid", document: user(`<user id="1"><name>John</name><age>30</age><email>j@example.com</email><role>admin</role></user><user id="2"><name>Jane</name><phone>123</phone><role xsi:nil="true"/></user>`)},
+		{name: "empty", document: user(``)},
+		{name: "invalid integer", document: user(`<user id="1"><name>John</name><age>-1</age><email>e</email><role>user</role></user>`), wantErr: "/users/user/age: value '-1' is not a valid nonNegativeInteger"},
+		{name: "enumeration of included type", document: user(`<user id="1"><name>John</name><email>e</email><role>guest</role></user><user id="2"><name>Jane</name><email>e</email><role>root</role></user>`), wantErr: "/users/user[2]/role: value 'root' is not one of admin, user"},
+		{name: "missing element", document: user(`<user id="1"><name>John</name><role>user</role></user>`), wantErr: "/users/user: content does not match the schema, missing required element(s): one of email|phone"},
+		{name: "extension", document: user(`<admin id="1"><name>John</name><email>e</email><role>admin</role><permissions>all</permissions></admin>`)},
+		{name: "extension element out of order", document: user(`<admin id="1"><permissions>all</permissions><name>John</name><email>e</email><role>admin</role></admin>`), wantErr: "/users/admin/permissions: element is not expected here, expected name"},
+		{name: "element out of order", document: user(`<user id="1"><name>John</name><role>user</role><email>e</email></user>`), wantErr: "/users/user/role: element is not expected here, expected one of age, email, phone"},
+		{name: "missing extension element", document: user(`<admin id="1"><name>John</name><email>e</email><role>admin</role></admin>`), wantErr: "/users/admin: content does not match the schema, missing required element(s): permissions"},
+		{name: "unexpected element", document: user(`<user id="1"><name>John</name><email>e</email><role>user</role><extra/></user>`), wantErr: "/users/user/extra: element is not expected"},
+		{name: "required attribute", document: user(`<user><name>John</name><email>e</email><role>user</role></user>`), wantErr: "/users/user: required attribute id is missing"},
+		{name: "unqualified element", document: `<users><user id="1"/></users>`, wantErr: "no global element users is declared"},
+		{name: "malformed", document: `<users>`, wantErr: "invalid XML"},
+		{name: "soap envelope", document: `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>` + user(`<user id="x"><name>John</name><email>e</email><role>user</role></user>`) + `</soap:Body></soap:Envelope>`, wantErr: "/Envelope/Body/users/user/@id: value 'x' is not a valid int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := schema.Validate([]byte(tt.document))
+
+			if tt.wantErr == "" && len(errs) > 0 {
+				t.Errorf("unexpected errors %v", errs)
+			}
+
+			if tt.wantErr != "" && !strings.Contains(strings.Join(errs, "\n"), tt.wantErr) {
+				t.Errorf("expected error %#v, got %v", tt.wantErr, errs)
+			}
+		})
+	}
+}
+
+func TestCheckBuiltinType(t *testing.T) {
+	tests := []struct {
+		typeName string
+		value    string
+		valid    bool
+	}{
+		{"boolean", "true", true},
+		{"boolean", "yes", false},
+		{"decimal", "-1.50", true},
+		{"decimal", "1e3", false},
+		{"byte", "127", true},
+		{"byte", "128", false},
+		{"unsignedLong", "18446744073709551615", true},
+		{"date", "2020-01-31", true},
+		{"dateTime", "2020-01-31T10:00:00.5Z", true},
+		{"dateTime", "2020-01-31 10:00:00", false},
+		{"duration", "P1DT2H", true},
+		{"duration", "P", false},
+		{"hexBinary", "0fA1", true},
+		{"base64Binary", "aGVsbG8=", true},
+		{"string", "anything", true},
+	}
+
+	for _, tt := range tests {
+		err := checkBuiltinType(tt.typeName, tt.value)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s '%s': expected valid %t, got %v", tt.typeName, tt.value, tt.valid, err)
+		}
+	}
+}